@@ -1,3 +1,10 @@
+//go:build windows && aimcli
+
+// Package internal's Arsenal Image Mounter backend is opt-in: it shells out
+// to a Windows-only CLI and requires Administrator privileges to create a
+// block device, whereas the default imagefs backends (see internal/imagefs)
+// read E01/raw/VHD images directly without mounting anything. Build with
+// `-tags aimcli` on Windows to enable it.
 package internal
 
 import (
@@ -9,11 +16,6 @@ import (
 
 const aimCliPath = `C:\Program Files\Arsenal-Image-Mounter-v3.12.331\Arsenal-Image-Mounter-v3.12.331\aim_cli.exe`
 
-type MountResult struct {
-	MountPoint   string
-	DeviceNumber string
-}
-
 // MountE01 mounts an E01 image using Arsenal Image Mounter CLI
 func MountE01(imagePath string) (*MountResult, error) {
 	cmd := exec.Command(
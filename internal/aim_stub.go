@@ -0,0 +1,20 @@
+//go:build !(windows && aimcli)
+
+package internal
+
+import "errors"
+
+// ErrAIMUnsupported is returned by MountE01/Dismount on builds where the
+// Arsenal Image Mounter backend was not compiled in (anything other than
+// `GOOS=windows -tags aimcli`). Scanning an E01 no longer requires it: see
+// internal/imagefs, which reads E01/raw/VHD images directly without
+// mounting a block device.
+var ErrAIMUnsupported = errors.New("Arsenal Image Mounter support was not built into this binary (rebuild on windows with -tags aimcli)")
+
+func MountE01(imagePath string) (*MountResult, error) {
+	return nil, ErrAIMUnsupported
+}
+
+func Dismount(deviceNumber string) error {
+	return ErrAIMUnsupported
+}
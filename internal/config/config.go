@@ -0,0 +1,112 @@
+// Package config centralizes the tunables that used to be hard-coded across
+// main.go, scanner.ScanForOnions, and tor.CheckOnions: the Tor proxy
+// address, per-onion timeout, worker concurrency, scan depth, verbosity,
+// and which ports to probe. A forensic run over a large E01 can easily
+// produce thousands of onions, so these need to be adjustable rather than
+// baked in the way the original 30-second sleep and port 443 were.
+package config
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"onion-finder/internal/scanner"
+	"onion-finder/internal/tor"
+)
+
+// Config holds every user-adjustable setting for a single run.
+type Config struct {
+	// TorProxyAddress is the Tor SOCKS5 proxy dialers connect through.
+	TorProxyAddress string
+
+	// Timeout bounds every per-onion operation: the SOCKS connect, the
+	// HTTP(S) round trip, and the TLS handshake.
+	Timeout time.Duration
+
+	// Workers is how many files (during the scan) or onions (during the
+	// Tor check) are processed concurrently.
+	Workers int
+
+	// Verbose enables LogInfo output; LogError always prints regardless.
+	Verbose bool
+
+	// KeepMounted controls whether a mount acquired through -mount (see
+	// internal.MountImage) is left mounted after the run instead of being
+	// dismounted automatically.
+	KeepMounted bool
+
+	// ScanDepth caps how many directory levels the filesystem walk
+	// descends below the scan root.
+	ScanDepth int
+
+	// Resume skips files already recorded in scanner.DefaultScanOptions's
+	// CheckpointPath from a previous, interrupted run of the same image,
+	// instead of re-scanning them.
+	Resume bool
+
+	// Ports lists the ports to probe on each onion during the Tor check.
+	Ports []int
+
+	// BootstrapWait is how long main waits after the scan/extract phases
+	// finish before checking Tor availability, giving a freshly-started
+	// Tor daemon time to bootstrap its circuits. 0 skips the wait.
+	BootstrapWait time.Duration
+}
+
+// Default returns the tool's default settings: the Tor Browser SOCKS proxy,
+// a 20s per-onion timeout, 8 concurrent workers, 256 levels of directory
+// depth, quiet logging, port 443 only, and a 30s post-scan wait for Tor to
+// bootstrap. The proxy, port 443 and the 30s wait were hard-coded before.
+func Default() Config {
+	return Config{
+		TorProxyAddress: tor.DefaultSOCKSAddr,
+		Timeout:         20 * time.Second,
+		Workers:         8,
+		Verbose:         false,
+		KeepMounted:     false,
+		ScanDepth:       256,
+		Resume:          false,
+		Ports:           []int{443},
+		BootstrapWait:   30 * time.Second,
+	}
+}
+
+// ScanOptions derives the scanner.ScanOptions for this run's filesystem
+// walk from c, keeping every other scanner.DefaultScanOptions() default
+// (file-size cap, Windows exclusion list, symlink handling) as-is.
+func (c Config) ScanOptions() scanner.ScanOptions {
+	opts := scanner.DefaultScanOptions()
+	opts.MaxDepth = c.ScanDepth
+	opts.Resume = c.Resume
+	opts.Workers = c.Workers
+	opts.Verbose = c.Verbose
+	return opts
+}
+
+// CheckOptions derives the tor.CheckOptions for this run's onion check
+// from c, keeping every other tor.DefaultCheckOptions() default (mode,
+// batching, TLS harvesting) as-is.
+func (c Config) CheckOptions() tor.CheckOptions {
+	opts := tor.DefaultCheckOptions()
+	opts.ProxyAddress = c.TorProxyAddress
+	opts.Timeout = c.Timeout
+	opts.Concurrency = c.Workers
+	opts.Ports = c.Ports
+	return opts
+}
+
+// LogInfo prints a progress message only when Verbose is set — detail
+// that's noise in a quiet/batch run but useful while watching a scan live.
+func (c Config) LogInfo(format string, args ...interface{}) {
+	if !c.Verbose {
+		return
+	}
+	fmt.Printf("[*] "+format+"\n", args...)
+}
+
+// LogError prints an error message to stderr unconditionally; errors are
+// never suppressed by Verbose.
+func (c Config) LogError(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, "[-] "+format+"\n", args...)
+}
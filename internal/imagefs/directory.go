@@ -0,0 +1,22 @@
+package imagefs
+
+import (
+	"io"
+	"io/fs"
+	"os"
+)
+
+// directorySource exposes a plain directory tree (e.g. an already-mounted
+// image, or a folder of exported files) as-is, via os.DirFS.
+type directorySource struct{}
+
+func (directorySource) Open(path string) (fs.FS, io.Closer, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !info.IsDir() {
+		return nil, nil, os.ErrInvalid
+	}
+	return os.DirFS(path), io.NopCloser(nil), nil
+}
@@ -0,0 +1,249 @@
+package imagefs
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"sync"
+)
+
+// ewfSource is a pure-Go reader for single-segment EnCase Evidence Files
+// (EWF/E01, "EnCase 1" format). It understands just enough of the section
+// layout to reconstruct the logical disk image: it does not verify CRCs,
+// parse the hash/error2 sections, or span multiple .E01/.E02/... segments.
+// Multi-segment images and the newer EWF2 (Ex01/Lx01) format fall outside
+// this scope; for those, mount the image through the ewfmount backend
+// instead (see internal/mountbackend.go).
+type ewfSource struct{}
+
+const ewfSignature = "EVF\x09\x0d\x0a\xff\x00"
+
+func (ewfSource) Open(path string) (fs.FS, io.Closer, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	img, err := openEWFImage(f)
+	if err != nil {
+		f.Close()
+		return nil, nil, err
+	}
+
+	return newSingleFileFS(sourceName(path), img, img.logicalSize), f, nil
+}
+
+func sourceName(path string) string {
+	name := path
+	for i := len(path) - 1; i >= 0; i-- {
+		if path[i] == '/' || path[i] == '\\' {
+			name = path[i+1:]
+			break
+		}
+	}
+	return name
+}
+
+// ewfChunkOffset describes where one chunk lives in the sectors section and
+// whether it is zlib-compressed (the high bit of the on-disk offset).
+type ewfChunkOffset struct {
+	offset     int64
+	compressed bool
+}
+
+// ewfImage implements io.ReaderAt over the logical (uncompressed) disk
+// image described by an EWF segment file's volume + table sections.
+type ewfImage struct {
+	file          *os.File
+	bytesPerChunk int64
+	logicalSize   int64
+	chunks        []ewfChunkOffset
+
+	mu         sync.Mutex
+	cachedIdx  int
+	cachedData []byte
+}
+
+func openEWFImage(f *os.File) (*ewfImage, error) {
+	header := make([]byte, 13)
+	if _, err := io.ReadFull(f, header); err != nil {
+		return nil, err
+	}
+	if string(header[:8]) != ewfSignature {
+		return nil, fmt.Errorf("imagefs: not an EWF/E01 file (bad signature)")
+	}
+
+	img := &ewfImage{file: f, cachedIdx: -1}
+
+	var sectorsSectionStart int64
+	var bytesPerSector int64 = 512
+	var sectorCount int64
+	var sectorsPerChunk int64 = 64
+
+	// Sections form a singly linked list: each 76-byte descriptor gives a
+	// type name, the absolute offset of the next descriptor, and the size
+	// of the section's own data.
+	offset := int64(13)
+	for {
+		desc := make([]byte, 76)
+		if _, err := f.ReadAt(desc, offset); err != nil {
+			return nil, fmt.Errorf("imagefs: reading EWF section descriptor: %w", err)
+		}
+
+		typeName := string(bytes.TrimRight(desc[:16], "\x00"))
+		next := int64(binary.LittleEndian.Uint64(desc[16:24]))
+		size := int64(binary.LittleEndian.Uint64(desc[24:32]))
+
+		switch typeName {
+		case "volume", "disk":
+			vol := make([]byte, size)
+			if _, err := f.ReadAt(vol, offset+76); err == nil && len(vol) >= 28 {
+				sectorCount = int64(binary.LittleEndian.Uint32(vol[4:8]))
+				sectorsPerChunk = int64(binary.LittleEndian.Uint32(vol[8:12]))
+				bytesPerSector = int64(binary.LittleEndian.Uint32(vol[12:16]))
+			}
+		case "sectors":
+			sectorsSectionStart = offset + 76
+		case "table":
+			if err := img.readTableSection(f, offset+76, size, sectorsSectionStart); err != nil {
+				return nil, err
+			}
+		case "done", "":
+			goto done
+		}
+
+		if next <= offset {
+			break
+		}
+		offset = next
+	}
+
+done:
+	if bytesPerSector <= 0 {
+		bytesPerSector = 512
+	}
+	if sectorsPerChunk <= 0 {
+		sectorsPerChunk = 64
+	}
+	img.bytesPerChunk = bytesPerSector * sectorsPerChunk
+	if sectorCount > 0 {
+		img.logicalSize = sectorCount * bytesPerSector
+	} else {
+		img.logicalSize = int64(len(img.chunks)) * img.bytesPerChunk
+	}
+
+	if len(img.chunks) == 0 {
+		return nil, fmt.Errorf("imagefs: no table section found in EWF file")
+	}
+
+	return img, nil
+}
+
+// readTableSection parses chunk offset entries. Each entry is a 32-bit
+// little-endian offset relative to the start of the associated sectors
+// section; the high bit marks the chunk as zlib-compressed.
+func (img *ewfImage) readTableSection(f *os.File, dataOffset, dataSize, sectorsStart int64) error {
+	if dataSize < 4 {
+		return fmt.Errorf("imagefs: truncated EWF table section")
+	}
+
+	hdr := make([]byte, 4)
+	if _, err := f.ReadAt(hdr, dataOffset); err != nil {
+		return err
+	}
+	entryCount := int64(binary.LittleEndian.Uint32(hdr))
+
+	// Table entries begin after a 24-byte header (entry count, padding,
+	// base offset, CRC).
+	entries := make([]byte, entryCount*4)
+	if _, err := f.ReadAt(entries, dataOffset+24); err != nil {
+		return err
+	}
+
+	for i := int64(0); i < entryCount; i++ {
+		raw := binary.LittleEndian.Uint32(entries[i*4 : i*4+4])
+		compressed := raw&0x80000000 != 0
+		rel := int64(raw &^ 0x80000000)
+		img.chunks = append(img.chunks, ewfChunkOffset{
+			offset:     sectorsStart + rel,
+			compressed: compressed,
+		})
+	}
+
+	return nil
+}
+
+func (img *ewfImage) ReadAt(p []byte, off int64) (int, error) {
+	total := 0
+	for total < len(p) {
+		chunkIdx := int((off + int64(total)) / img.bytesPerChunk)
+		if chunkIdx >= len(img.chunks) {
+			if total > 0 {
+				return total, nil
+			}
+			return 0, io.EOF
+		}
+
+		chunk, err := img.readChunk(chunkIdx)
+		if err != nil {
+			return total, err
+		}
+
+		chunkOff := (off + int64(total)) % img.bytesPerChunk
+		if chunkOff >= int64(len(chunk)) {
+			return total, io.EOF
+		}
+
+		n := copy(p[total:], chunk[chunkOff:])
+		total += n
+	}
+	return total, nil
+}
+
+// readChunk returns the decompressed bytes of chunk idx, caching the most
+// recently decompressed chunk since reads tend to be sequential.
+func (img *ewfImage) readChunk(idx int) ([]byte, error) {
+	img.mu.Lock()
+	defer img.mu.Unlock()
+
+	if img.cachedIdx == idx {
+		return img.cachedData, nil
+	}
+
+	chunk := img.chunks[idx]
+
+	// We don't track each chunk's exact on-disk length (that requires
+	// diffing against the next chunk's offset, including across table
+	// segments), so read a full chunk's worth of bytes; for a compressed
+	// chunk the zlib reader below simply stops at the stream's end
+	// marker and ignores the trailing bytes that belong to the next one.
+	raw := make([]byte, img.bytesPerChunk)
+	n, err := img.file.ReadAt(raw, chunk.offset)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	raw = raw[:n]
+
+	var data []byte
+	if chunk.compressed {
+		zr, err := zlib.NewReader(bytes.NewReader(raw))
+		if err != nil {
+			return nil, fmt.Errorf("imagefs: decompressing EWF chunk %d: %w", idx, err)
+		}
+		defer zr.Close()
+		data, err = io.ReadAll(zr)
+		if err != nil {
+			return nil, fmt.Errorf("imagefs: decompressing EWF chunk %d: %w", idx, err)
+		}
+	} else {
+		data = raw
+	}
+
+	img.cachedIdx = idx
+	img.cachedData = data
+	return data, nil
+}
@@ -0,0 +1,112 @@
+// Package imagefs exposes forensic disk images as a stdlib io/fs.FS so the
+// scanner can walk them directly, without mounting a block device and
+// without needing Administrator/root privileges. Each supported container
+// format (E01/EWF, raw/dd, VHD/VHDX, plain directories) is implemented as an
+// ImageSource and registered under a Format key; callers normally go through
+// Open, which sniffs the file and picks the right backend automatically.
+package imagefs
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"strings"
+)
+
+// Format identifies a container format recognized by imagefs.
+type Format string
+
+const (
+	FormatE01       Format = "e01"
+	FormatRaw       Format = "raw"
+	FormatVHD       Format = "vhd"
+	FormatDirectory Format = "directory"
+)
+
+// ImageSource opens a forensic image and exposes its contents as a read-only
+// fs.FS. The returned io.Closer must be closed once the caller is done
+// scanning, to release any underlying file handles.
+type ImageSource interface {
+	Open(path string) (fs.FS, io.Closer, error)
+}
+
+// registry maps a Format to the backend that handles it. The built-in
+// backends are listed here directly; Register adds or overrides one at
+// run time.
+var registry = map[Format]ImageSource{
+	FormatDirectory: directorySource{},
+	FormatRaw:       rawSource{},
+	FormatVHD:       vhdSource{},
+	FormatE01:       ewfSource{},
+}
+
+// Register installs or overrides the ImageSource used for a given format.
+// Nothing in this repository calls it; the built-in backends are already
+// in registry.
+func Register(format Format, source ImageSource) {
+	registry[format] = source
+}
+
+// DetectFormat sniffs path to determine which container format it holds. A
+// plain directory is detected by stat-ing the path; everything else is
+// detected by magic bytes rather than file extension, since forensic images
+// are frequently renamed or extensionless.
+func DetectFormat(path string) (Format, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+	if info.IsDir() {
+		return FormatDirectory, nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	magic := make([]byte, 8)
+	n, err := f.Read(magic)
+	if err != nil && err != io.EOF {
+		return "", err
+	}
+	magic = magic[:n]
+
+	switch {
+	case bytes.HasPrefix(magic, []byte("EVF\x09\x0d\x0a\xff\x00")):
+		return FormatE01, nil
+	case bytes.HasPrefix(magic, []byte("conectix")):
+		return FormatVHD, nil
+	case bytes.HasPrefix(magic, []byte("vhdxfile")):
+		return FormatVHD, nil
+	default:
+		if strings.HasSuffix(strings.ToLower(path), ".e01") {
+			return FormatE01, nil
+		}
+		return FormatRaw, nil
+	}
+}
+
+// Open detects the format of path and opens it through the matching
+// ImageSource. Use this instead of calling a specific backend directly
+// unless the format is already known.
+func Open(path string) (fs.FS, io.Closer, error) {
+	format, err := DetectFormat(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("imagefs: detect format: %w", err)
+	}
+
+	source, ok := registry[format]
+	if !ok {
+		return nil, nil, fmt.Errorf("imagefs: no backend registered for format %q", format)
+	}
+
+	fsys, closer, err := source.Open(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("imagefs: open %s as %s: %w", path, format, err)
+	}
+	return fsys, closer, nil
+}
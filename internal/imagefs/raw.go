@@ -0,0 +1,28 @@
+package imagefs
+
+import (
+	"io"
+	"io/fs"
+	"os"
+)
+
+// rawSource exposes a raw/dd byte-for-byte disk image as a single file. No
+// partition table or filesystem parsing happens here; the byte-level
+// scanner already copes with binary content and embedded strings, so there
+// is no need to reconstruct the original filesystem just to look for IOCs.
+type rawSource struct{}
+
+func (rawSource) Open(path string) (fs.FS, io.Closer, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, nil, err
+	}
+
+	return newSingleFileFS(info.Name(), f, info.Size()), f, nil
+}
@@ -0,0 +1,110 @@
+package imagefs
+
+import (
+	"io"
+	"io/fs"
+	"time"
+)
+
+// singleFileFS adapts a single io.ReaderAt (the reconstructed contents of a
+// container that carries no filesystem metadata of its own, such as a raw
+// dd image, a fixed-format VHD, or an EWF segment before it has been parsed
+// by a real filesystem driver) into an fs.FS with one entry, name. This lets
+// the chunked byte-level scanner run over the container exactly as it would
+// over any other file, without requiring a full NTFS/ext/APFS implementation.
+type singleFileFS struct {
+	name string
+	data io.ReaderAt
+	size int64
+}
+
+func newSingleFileFS(name string, data io.ReaderAt, size int64) fs.FS {
+	return singleFileFS{name: name, data: data, size: size}
+}
+
+func (s singleFileFS) Open(name string) (fs.File, error) {
+	if name == "." {
+		return &singleFileDir{entries: []fs.DirEntry{fs.FileInfoToDirEntry(singleFileInfo{s})}}, nil
+	}
+	if name != s.name {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	return &singleFile{fs: s, section: io.NewSectionReader(s.data, 0, s.size)}, nil
+}
+
+// Stat special-cases "." so fs.Stat(fsys, ".") sees a synthetic root
+// directory rather than ErrNotExist: fs.WalkDir and the scanner's own
+// walk both stat the root before descending into it.
+func (s singleFileFS) Stat(name string) (fs.FileInfo, error) {
+	if name == "." {
+		return singleRootInfo{}, nil
+	}
+	if name != s.name {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: fs.ErrNotExist}
+	}
+	return singleFileInfo{s}, nil
+}
+
+// ReadDir makes the root directory (".") list the single synthetic file, so
+// fs.WalkDir(fsys, ".") finds it like it would any real directory entry.
+func (s singleFileFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	if name != "." {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrNotExist}
+	}
+	return []fs.DirEntry{fs.FileInfoToDirEntry(singleFileInfo{s})}, nil
+}
+
+type singleFile struct {
+	fs      singleFileFS
+	section *io.SectionReader
+}
+
+func (f *singleFile) Stat() (fs.FileInfo, error) { return singleFileInfo{f.fs}, nil }
+func (f *singleFile) Read(b []byte) (int, error) { return f.section.Read(b) }
+func (f *singleFile) Close() error               { return nil }
+
+type singleFileInfo struct{ s singleFileFS }
+
+func (i singleFileInfo) Name() string       { return i.s.name }
+func (i singleFileInfo) Size() int64        { return i.s.size }
+func (i singleFileInfo) Mode() fs.FileMode  { return 0o444 }
+func (i singleFileInfo) ModTime() time.Time { return time.Time{} }
+func (i singleFileInfo) IsDir() bool        { return false }
+func (i singleFileInfo) Sys() any           { return nil }
+
+// singleFileDir is the fs.File returned when "." is opened directly (as
+// opposed to statted or read via ReadDir): it has no content of its own,
+// only the single synthetic entry.
+type singleFileDir struct {
+	entries []fs.DirEntry
+	read    bool
+}
+
+func (d *singleFileDir) Stat() (fs.FileInfo, error) { return singleRootInfo{}, nil }
+func (d *singleFileDir) Read([]byte) (int, error)   { return 0, io.EOF }
+func (d *singleFileDir) Close() error               { return nil }
+
+func (d *singleFileDir) ReadDir(n int) ([]fs.DirEntry, error) {
+	if d.read {
+		if n <= 0 {
+			return nil, nil
+		}
+		return nil, io.EOF
+	}
+	d.read = true
+	if n > 0 && n < len(d.entries) {
+		return d.entries[:n], nil
+	}
+	return d.entries, nil
+}
+
+// singleRootInfo describes the synthetic "." directory that wraps the
+// single file singleFileFS serves.
+type singleRootInfo struct{}
+
+func (singleRootInfo) Name() string       { return "." }
+func (singleRootInfo) Size() int64        { return 0 }
+func (singleRootInfo) Mode() fs.FileMode  { return fs.ModeDir | 0o555 }
+func (singleRootInfo) ModTime() time.Time { return time.Time{} }
+func (singleRootInfo) IsDir() bool        { return true }
+func (singleRootInfo) Sys() any           { return nil }
@@ -0,0 +1,56 @@
+package imagefs
+
+import (
+	"bytes"
+	"io"
+	"io/fs"
+	"testing"
+)
+
+// TestSingleFileFSWalkable guards against the regression where Stat(".")
+// returned ErrNotExist: every non-directory backend (raw, E01, VHD) wraps
+// its content in a singleFileFS, and scanner.walk stats "." before
+// descending, so an unstattable root silently dropped the whole image.
+func TestSingleFileFSWalkable(t *testing.T) {
+	content := []byte("some disk image bytes containing hbrpoigf3cbfnobm2o4rak3vrjnvgfygwwqc5hyfsxmecosfogyr5xkx.onion")
+	fsys := newSingleFileFS("image.raw", bytes.NewReader(content), int64(len(content)))
+
+	info, err := fs.Stat(fsys, ".")
+	if err != nil {
+		t.Fatalf("Stat(\".\") = %v, want a synthetic root directory", err)
+	}
+	if !info.IsDir() {
+		t.Fatalf("Stat(\".\").IsDir() = false, want true")
+	}
+
+	var found []string
+	err = fs.WalkDir(fsys, ".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			found = append(found, path)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("WalkDir: %v", err)
+	}
+	if len(found) != 1 || found[0] != "image.raw" {
+		t.Fatalf("WalkDir found %v, want [image.raw]", found)
+	}
+
+	f, err := fsys.Open("image.raw")
+	if err != nil {
+		t.Fatalf("Open(image.raw): %v", err)
+	}
+	defer f.Close()
+
+	got, err := io.ReadAll(f)
+	if err != nil {
+		t.Fatalf("reading file: %v", err)
+	}
+	if !bytes.Equal(got, content) {
+		t.Fatalf("read content %q, want %q", got, content)
+	}
+}
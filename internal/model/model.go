@@ -0,0 +1,25 @@
+// Package model holds the small, dependency-free data types shared between
+// the scanner, report writers, and Tor checker. Keeping them in their own
+// package avoids import cycles between internal/scanner and internal/tor.
+package model
+
+// Onion represents a single Tor v3 onion address discovered during a scan,
+// together with the path of the file it was found in.
+type Onion struct {
+	Value string
+	Path  string
+}
+
+// Hit is a single match produced by the scanner's rule engine: some rule
+// (a Tor onion, a BTC address, a PGP key block, ...) matched at a given
+// offset in a given file, in the encoding the bytes were found in. This
+// generalizes Onion to cover every IOC category the rule engine supports,
+// while Onion itself stays around for the narrower onion-only code paths
+// (tor.CheckOnions and friends) that don't need the other fields.
+type Hit struct {
+	RuleName string
+	Value    string
+	Path     string
+	Offset   int64
+	Encoding string
+}
@@ -0,0 +1,231 @@
+package internal
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+)
+
+// MountBackend mounts a forensic image through an external FUSE helper and
+// exposes the resulting mount point. Unlike internal/imagefs, which parses
+// E01/raw/VHD containers in-process as a stdlib fs.FS without needing a
+// mount at all, a MountBackend shells out to a helper that exposes a real
+// mounted filesystem — useful for formats imagefs doesn't parse itself
+// (AFF, qcow2) or when a caller needs an actual mount point rather than an
+// fs.FS. See aim.go for the separate, Windows-only Arsenal Image Mounter
+// backend, which predates this interface and is selected by build tag
+// rather than by format.
+type MountBackend interface {
+	// Name identifies the backend in logs and in the mount state file
+	// (see mountstate.go), so a later DismountImage knows which backend
+	// to release a mount through.
+	Name() string
+	// Mount mounts imagePath under a fresh temporary directory and
+	// returns the resulting mount point.
+	Mount(imagePath string) (*MountResult, error)
+	// Dismount releases a mount previously returned by Mount.
+	Dismount(mountPoint string) error
+}
+
+// mountBackends maps a sniffed image format (see DetectMountFormat) to the
+// MountBackend that handles it.
+var mountBackends = map[string]MountBackend{
+	"ewf":   ewfmountBackend{},
+	"aff":   affuseBackend{},
+	"qcow2": xmountBackend{format: "qcow2"},
+	"raw":   xmountBackend{format: "raw"},
+}
+
+// mountBackendsByName maps a MountBackend.Name() back to the backend, so
+// DismountImage can release a mount recorded under that name in the mount
+// state file without re-sniffing the original image. Dismount doesn't
+// depend on xmountBackend's format, so the zero-value instance works for
+// either format that name was originally mounted under.
+var mountBackendsByName = map[string]MountBackend{
+	ewfmountBackend{}.Name(): ewfmountBackend{},
+	affuseBackend{}.Name():   affuseBackend{},
+	xmountBackend{}.Name():   xmountBackend{},
+}
+
+// DetectMountFormat sniffs imagePath's magic bytes to choose a mount
+// backend, the same way imagefs.DetectFormat does for the in-process
+// readers, rather than trusting the file extension (forensic images are
+// frequently renamed or extensionless). It additionally recognizes AFF and
+// qcow2, which imagefs can't read natively but a FUSE helper can still
+// mount.
+func DetectMountFormat(imagePath string) (string, error) {
+	f, err := os.Open(imagePath)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	magic := make([]byte, 8)
+	n, err := f.Read(magic)
+	if err != nil && err != io.EOF {
+		return "", err
+	}
+	magic = magic[:n]
+
+	switch {
+	case bytes.HasPrefix(magic, []byte("EVF\x09\x0d\x0a\xff\x00")):
+		return "ewf", nil
+	case bytes.HasPrefix(magic, []byte("AFF")):
+		return "aff", nil
+	case bytes.HasPrefix(magic, []byte("QFI\xfb")):
+		return "qcow2", nil
+	default:
+		return "raw", nil
+	}
+}
+
+// MountImage mounts imagePath through the MountBackend selected by
+// DetectMountFormat, records the resulting mount in the mount state file
+// (see mountstate.go) so a crash or a later `-dismount <id>` can find it,
+// and returns the MountEntry the caller should hold onto for DismountImage.
+func MountImage(imagePath string) (MountEntry, error) {
+	format, err := DetectMountFormat(imagePath)
+	if err != nil {
+		return MountEntry{}, fmt.Errorf("detect mount format: %w", err)
+	}
+
+	backend, ok := mountBackends[format]
+	if !ok {
+		return MountEntry{}, fmt.Errorf("no mount backend available for format %q", format)
+	}
+
+	result, err := backend.Mount(imagePath)
+	if err != nil {
+		return MountEntry{}, fmt.Errorf("%s: %w", backend.Name(), err)
+	}
+
+	entry, err := RecordMount(backend.Name(), imagePath, result)
+	if err != nil {
+		_ = backend.Dismount(result.MountPoint)
+		return MountEntry{}, err
+	}
+
+	_ = LogMount(imagePath, result.DeviceNumber, result.MountPoint, false)
+	return entry, nil
+}
+
+// DismountImage releases the mount recorded under id, using whichever
+// backend mounted it, and removes it from the mount state file.
+func DismountImage(id string) error {
+	entries, err := loadMountState()
+	if err != nil {
+		return err
+	}
+
+	for _, e := range entries {
+		if e.ID != id {
+			continue
+		}
+		backend, ok := mountBackendsByName[e.Backend]
+		if !ok {
+			return fmt.Errorf("unknown mount backend %q for mount %q", e.Backend, id)
+		}
+		if err := backend.Dismount(e.MountPoint); err != nil {
+			return err
+		}
+		_ = LogDismount(e.DeviceNumber)
+		return RemoveMount(id)
+	}
+
+	return fmt.Errorf("no active mount with id %q", id)
+}
+
+// ewfmountBackend mounts EWF/E01 images via the ewfmount FUSE helper from
+// libewf-tools.
+type ewfmountBackend struct{}
+
+func (ewfmountBackend) Name() string { return "ewfmount" }
+
+func (ewfmountBackend) Mount(imagePath string) (*MountResult, error) {
+	return fuseMount("ewfmount", imagePath)
+}
+
+func (ewfmountBackend) Dismount(mountPoint string) error {
+	return fuseUnmount(mountPoint)
+}
+
+// xmountBackend mounts raw/dd and qcow2 images via xmount, which can also
+// re-present one input format as another; onion-finder only ever asks for
+// the image's own native format back out, since all it needs is read
+// access to the original bytes. format is xmount's own format name ("raw"
+// or "qcow2"), passed to both --in and --out, which xmount requires on
+// every invocation regardless of whether a conversion is wanted.
+type xmountBackend struct{ format string }
+
+func (xmountBackend) Name() string { return "xmount" }
+
+func (b xmountBackend) Mount(imagePath string) (*MountResult, error) {
+	return fuseMount("xmount", imagePath, "--in", b.format, "--out", b.format)
+}
+
+func (xmountBackend) Dismount(mountPoint string) error {
+	return fuseUnmount(mountPoint)
+}
+
+// affuseBackend mounts AFF (Advanced Forensic Format) images via affuse
+// from afflib-tools.
+type affuseBackend struct{}
+
+func (affuseBackend) Name() string { return "affuse" }
+
+func (affuseBackend) Mount(imagePath string) (*MountResult, error) {
+	return fuseMount("affuse", imagePath)
+}
+
+func (affuseBackend) Dismount(mountPoint string) error {
+	return fuseUnmount(mountPoint)
+}
+
+// fuseMount creates a fresh mount point under os.TempDir, runs tool against
+// imagePath (with any extraArgs inserted before the image path and mount
+// point — xmount requires its own --in/--out flags there), and waits for
+// the FUSE daemon to attach before returning. Like ewfmount/xmount/affuse
+// themselves, it assumes the tool daemonizes once mounted rather than
+// staying in the foreground.
+func fuseMount(tool, imagePath string, extraArgs ...string) (*MountResult, error) {
+	if _, err := exec.LookPath(tool); err != nil {
+		return nil, fmt.Errorf("%s not found in PATH (install libewf-tools/xmount/afflib-tools)", tool)
+	}
+
+	mountPoint, err := os.MkdirTemp("", "onion-finder-mount-*")
+	if err != nil {
+		return nil, err
+	}
+
+	args := append(append([]string{}, extraArgs...), imagePath, mountPoint)
+	if err := exec.Command(tool, args...).Run(); err != nil {
+		os.Remove(mountPoint)
+		return nil, err
+	}
+
+	entries, err := os.ReadDir(mountPoint)
+	if err != nil || len(entries) == 0 {
+		_ = fuseUnmount(mountPoint)
+		return nil, fmt.Errorf("mount point is empty, %s likely failed to attach", tool)
+	}
+
+	return &MountResult{MountPoint: mountPoint}, nil
+}
+
+// fuseUnmount releases a FUSE mount created by fuseMount, preferring
+// fusermount (Linux's standard FUSE unmount helper) and falling back to
+// umount for platforms that don't have it.
+func fuseUnmount(mountPoint string) error {
+	var err error
+	if _, lookErr := exec.LookPath("fusermount"); lookErr == nil {
+		err = exec.Command("fusermount", "-u", mountPoint).Run()
+	} else {
+		err = exec.Command("umount", mountPoint).Run()
+	}
+	if err != nil {
+		return err
+	}
+	return os.Remove(mountPoint)
+}
@@ -4,12 +4,20 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
-	"strings"
 	"time"
 )
 
 const logDir = "logs"
 
+// MountResult describes where a forensic image was mounted, either by a
+// MountBackend (ewfmount/xmount/affuse, see mountbackend.go; these leave
+// DeviceNumber empty) or by the optional Arsenal Image Mounter backend
+// (see aim.go).
+type MountResult struct {
+	MountPoint   string
+	DeviceNumber string
+}
+
 func getMountLogFile() (string, error) {
 	if err := os.MkdirAll(logDir, 0755); err != nil {
 		return "", err
@@ -61,43 +69,3 @@ func LogDismount(device string) error {
 	fmt.Fprintf(f, "Image was dismounted : %s\n\n", now)
 	return nil
 }
-
-func GetLastMountedDevice() (string, error) {
-	logFile, err := getMountLogFile()
-	if err != nil {
-		return "", err
-	}
-
-	data, err := os.ReadFile(logFile)
-	if err != nil {
-		return "", err
-	}
-
-	lines := strings.Split(string(data), "\n")
-
-	var lastDevice string
-	var dismounted bool
-
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-
-		if strings.HasPrefix(line, "Device:") {
-			lastDevice = strings.TrimSpace(strings.TrimPrefix(line, "Device:"))
-			dismounted = false
-		}
-
-		if strings.HasPrefix(line, "Image was dismounted") {
-			dismounted = true
-		}
-	}
-
-	if lastDevice == "" {
-		return "", fmt.Errorf("no mounted device found in log")
-	}
-
-	if dismounted {
-		return "", fmt.Errorf("last device already dismounted")
-	}
-
-	return lastDevice, nil
-}
@@ -0,0 +1,129 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// MountEntry records one active mount so a crash or a later invocation of
+// `-dismount <id>` can find and release it without the operator having to
+// remember a mount point or device number by hand. This supersedes the old
+// approach of recovering the single most recent mount by re-parsing the
+// human-readable log in mountlog.go.
+type MountEntry struct {
+	ID           string    `json:"id"`
+	Backend      string    `json:"backend"`
+	ImagePath    string    `json:"image_path"`
+	MountPoint   string    `json:"mount_point"`
+	DeviceNumber string    `json:"device_number,omitempty"`
+	MountedAt    time.Time `json:"mounted_at"`
+}
+
+// mountStateFile is "mounts.json" under the user's cache dir, e.g.
+// ~/.cache/onion-finder/mounts.json on Linux.
+func mountStateFile() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+
+	dir = filepath.Join(dir, "onion-finder")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, "mounts.json"), nil
+}
+
+func loadMountState() ([]MountEntry, error) {
+	path, err := mountStateFile()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []MountEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+func saveMountState(entries []MountEntry) error {
+	path, err := mountStateFile()
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, data, 0644)
+}
+
+// RecordMount appends a new MountEntry for a mount just created by a
+// MountBackend to the mount state file and returns it, with a freshly
+// generated ID.
+func RecordMount(backend, imagePath string, result *MountResult) (MountEntry, error) {
+	entries, err := loadMountState()
+	if err != nil {
+		return MountEntry{}, err
+	}
+
+	entry := MountEntry{
+		ID:           fmt.Sprintf("m%d", time.Now().UnixNano()),
+		Backend:      backend,
+		ImagePath:    imagePath,
+		MountPoint:   result.MountPoint,
+		DeviceNumber: result.DeviceNumber,
+		MountedAt:    time.Now(),
+	}
+
+	entries = append(entries, entry)
+	if err := saveMountState(entries); err != nil {
+		return MountEntry{}, err
+	}
+	return entry, nil
+}
+
+// RemoveMount deletes the entry with the given ID from the mount state
+// file.
+func RemoveMount(id string) error {
+	entries, err := loadMountState()
+	if err != nil {
+		return err
+	}
+
+	filtered := entries[:0]
+	found := false
+	for _, e := range entries {
+		if e.ID == id {
+			found = true
+			continue
+		}
+		filtered = append(filtered, e)
+	}
+	if !found {
+		return fmt.Errorf("no active mount with id %q", id)
+	}
+
+	return saveMountState(filtered)
+}
+
+// ListMounts returns every active mount recorded in the mount state file,
+// oldest first.
+func ListMounts() ([]MountEntry, error) {
+	return loadMountState()
+}
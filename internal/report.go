@@ -6,9 +6,14 @@ import (
 	"os"
 
 	"onion-finder/internal/model"
+	"onion-finder/internal/scanner"
 )
 
-func WriteOnionReport(filename string, onions []model.Onion) error {
+// WriteOnionReport writes the subset of hits produced by the built-in
+// tor-onion-v3 rule, in the plain "value | path" format tor.ParseOnionFile
+// expects. Hits from the other rules (BTC addresses, PGP blocks, ...) are
+// left out of this report.
+func WriteOnionReport(filename string, hits []model.Hit) error {
 	file, err := os.Create(filename)
 	if err != nil {
 		return err
@@ -18,8 +23,12 @@ func WriteOnionReport(filename string, onions []model.Onion) error {
 	writer := bufio.NewWriter(file)
 	defer writer.Flush()
 
-	for _, o := range onions {
-		line := fmt.Sprintf("%s | %s\n", o.Value, o.Path)
+	for _, h := range hits {
+		if h.RuleName != scanner.RuleTorOnionV3 {
+			continue
+		}
+
+		line := fmt.Sprintf("%s | %s\n", h.Value, h.Path)
 		if _, err := writer.WriteString(line); err != nil {
 			return err
 		}
@@ -0,0 +1,39 @@
+package report
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"time"
+)
+
+var csvHeader = []string{"rule", "value", "path", "offset", "encoding", "sha256", "status", "port", "timestamp"}
+
+// WriteCSV renders records as CSV with a header row.
+func WriteCSV(w io.Writer, records []Record) error {
+	cw := csv.NewWriter(w)
+
+	if err := cw.Write(csvHeader); err != nil {
+		return err
+	}
+
+	for _, r := range records {
+		row := []string{
+			r.RuleName,
+			r.Value,
+			r.Path,
+			strconv.FormatInt(r.Offset, 10),
+			r.Encoding,
+			r.SHA256,
+			r.Status,
+			strconv.Itoa(r.Port),
+			r.Timestamp.Format(time.RFC3339),
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
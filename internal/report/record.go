@@ -0,0 +1,102 @@
+// Package report turns scan output (model.Hit) and Tor-check output
+// (tor.Result) into a single flat schema, then renders that schema as an
+// ASCII table, JSON, CSV, or SARIF — so downstream tooling gets the same
+// fields regardless of which phase produced them or which format the user
+// asked for.
+package report
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"io/fs"
+	"time"
+
+	"onion-finder/internal/model"
+	"onion-finder/internal/tor"
+)
+
+// RuleNameTorCheck identifies records produced by a Tor availability check
+// rather than the filesystem scanner, in the same RuleName slot scanner
+// rules use.
+const RuleNameTorCheck = "tor-check"
+
+// Record is the common shape every report writer consumes. Fields that
+// don't apply to a given record's origin (Offset/Encoding/SHA256 for a Tor
+// check, Status for a filesystem hit) are left at their zero value.
+type Record struct {
+	RuleName  string    `json:"rule"`
+	Value     string    `json:"value"`
+	Path      string    `json:"path"`
+	Offset    int64     `json:"offset,omitempty"`
+	Encoding  string    `json:"encoding,omitempty"`
+	SHA256    string    `json:"sha256,omitempty"`
+	Status    string    `json:"status,omitempty"`
+	Port      int       `json:"port,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// FromHits converts scanner hits into records, filling in SHA256 by hashing
+// the containing file once per distinct path. fsys is the same filesystem
+// the hits were scanned from.
+func FromHits(fsys fs.FS, hits []model.Hit, ts time.Time) []Record {
+	sums := make(map[string]string, len(hits))
+	records := make([]Record, 0, len(hits))
+
+	for _, h := range hits {
+		sum, ok := sums[h.Path]
+		if !ok {
+			sum = sha256File(fsys, h.Path)
+			sums[h.Path] = sum
+		}
+
+		records = append(records, Record{
+			RuleName:  h.RuleName,
+			Value:     h.Value,
+			Path:      h.Path,
+			Offset:    h.Offset,
+			Encoding:  h.Encoding,
+			SHA256:    sum,
+			Timestamp: ts,
+		})
+	}
+
+	return records
+}
+
+// FromTorResults converts Tor onion-availability results into records. Port
+// is carried through so a list checked against several ports doesn't
+// collapse into indistinguishable rows.
+func FromTorResults(results []tor.Result, ts time.Time) []Record {
+	records := make([]Record, 0, len(results))
+
+	for _, r := range results {
+		records = append(records, Record{
+			RuleName:  RuleNameTorCheck,
+			Value:     r.Onion.Value,
+			Path:      r.Onion.Path,
+			Status:    string(r.Status),
+			Port:      r.Port,
+			Timestamp: ts,
+		})
+	}
+
+	return records
+}
+
+// sha256File hashes the named file within fsys, returning "" if it can't be
+// opened or read — a missing hash shouldn't stop a report from being written.
+func sha256File(fsys fs.FS, path string) string {
+	f, err := fsys.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return ""
+	}
+
+	return hex.EncodeToString(h.Sum(nil))
+}
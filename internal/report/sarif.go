@@ -0,0 +1,107 @@
+package report
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// Minimal SARIF 2.1.0 structures — just enough to carry a rule catalog plus
+// one result per record. See https://docs.oasis-open.org/sarif/sarif/v2.1.0
+// for the full spec; fields we don't populate (codeFlows, fixes, ...) are
+// simply omitted.
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name  string      `json:"name"`
+	Rules []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID string `json:"id"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	ByteOffset int64 `json:"byteOffset"`
+}
+
+// WriteSARIF renders records as a single SARIF 2.1.0 log with one run, so
+// hits can be consumed by SARIF-aware tooling (GitHub code scanning, IDE
+// plugins, ...) alongside other static-analysis results.
+func WriteSARIF(w io.Writer, records []Record) error {
+	seenRules := make(map[string]bool)
+	var rules []sarifRule
+	var results []sarifResult
+
+	for _, r := range records {
+		if !seenRules[r.RuleName] {
+			seenRules[r.RuleName] = true
+			rules = append(rules, sarifRule{ID: r.RuleName})
+		}
+
+		loc := sarifArtifactLocation{URI: r.Path}
+		var region *sarifRegion
+		if r.Offset > 0 {
+			region = &sarifRegion{ByteOffset: r.Offset}
+		}
+
+		results = append(results, sarifResult{
+			RuleID:  r.RuleName,
+			Message: sarifMessage{Text: r.Value},
+			Locations: []sarifLocation{{
+				PhysicalLocation: sarifPhysicalLocation{
+					ArtifactLocation: loc,
+					Region:           region,
+				},
+			}},
+		})
+	}
+
+	log := sarifLog{
+		Schema:  "https://raw.githubusercontent.com/oasis-tcs/sarif-spec/master/Schemata/sarif-schema-2.1.0.json",
+		Version: "2.1.0",
+		Runs: []sarifRun{{
+			Tool:    sarifTool{Driver: sarifDriver{Name: "onion-finder", Rules: rules}},
+			Results: results,
+		}},
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(log)
+}
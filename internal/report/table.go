@@ -0,0 +1,68 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+func line(w int) string {
+	return strings.Repeat("-", w)
+}
+
+func computeColumnWidths(records []Record) (ruleW, valueW, pathW, statusW, portW int) {
+	ruleW, valueW, pathW, statusW, portW = len("Rule"), len("Value"), len("Path"), len("Status"), len("Port")
+
+	for _, r := range records {
+		if len(r.RuleName) > ruleW {
+			ruleW = len(r.RuleName)
+		}
+		if len(r.Value) > valueW {
+			valueW = len(r.Value)
+		}
+		if len(r.Path) > pathW {
+			pathW = len(r.Path)
+		}
+		if len(r.Status) > statusW {
+			statusW = len(r.Status)
+		}
+		if n := len(strconv.Itoa(r.Port)); r.Port != 0 && n > portW {
+			portW = n
+		}
+	}
+	return
+}
+
+// WriteTable renders records in the same box-drawn ASCII layout as
+// tor.WriteResultsTable, with a leading Rule column since a table may now
+// mix hits from several rules in one file, and the onion column headed
+// Value. The Port cell is left blank for records with no port.
+func WriteTable(w io.Writer, records []Record) error {
+	ruleW, valueW, pathW, statusW, portW := computeColumnWidths(records)
+
+	border := fmt.Sprintf("+-%s-+-%s-+-%s-+-%s-+-%s-+\n", line(ruleW), line(valueW), line(pathW), line(statusW), line(portW))
+
+	if _, err := io.WriteString(w, border); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "| %-*s | %-*s | %-*s | %-*s | %-*s |\n", ruleW, "Rule", valueW, "Value", pathW, "Path", statusW, "Status", portW, "Port"); err != nil {
+		return err
+	}
+	if _, err := io.WriteString(w, border); err != nil {
+		return err
+	}
+
+	for _, r := range records {
+		port := ""
+		if r.Port != 0 {
+			port = strconv.Itoa(r.Port)
+		}
+		if _, err := fmt.Fprintf(w, "| %-*s | %-*s | %-*s | %-*s | %-*s |\n", ruleW, r.RuleName, valueW, r.Value, pathW, r.Path, statusW, r.Status, portW, port); err != nil {
+			return err
+		}
+	}
+
+	_, err := io.WriteString(w, border)
+	return err
+}
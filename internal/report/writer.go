@@ -0,0 +1,59 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+// Format selects how WriteTo/WriteFile render a set of records.
+type Format string
+
+const (
+	FormatTable Format = "table"
+	FormatJSON  Format = "json"
+	FormatCSV   Format = "csv"
+	FormatSARIF Format = "sarif"
+)
+
+// DefaultFilename returns the conventional output filename for f, used when
+// the caller didn't specify one explicitly.
+func (f Format) DefaultFilename() string {
+	switch f {
+	case FormatJSON:
+		return "report.json"
+	case FormatCSV:
+		return "report.csv"
+	case FormatSARIF:
+		return "report.sarif"
+	default:
+		return "report.txt"
+	}
+}
+
+var writers = map[Format]func(io.Writer, []Record) error{
+	FormatTable: WriteTable,
+	FormatJSON:  WriteJSON,
+	FormatCSV:   WriteCSV,
+	FormatSARIF: WriteSARIF,
+}
+
+// WriteTo renders records in the given format to w.
+func WriteTo(format Format, w io.Writer, records []Record) error {
+	writer, ok := writers[format]
+	if !ok {
+		return fmt.Errorf("report: unknown format %q", format)
+	}
+	return writer(w, records)
+}
+
+// WriteFile renders records in the given format to a new file at path.
+func WriteFile(format Format, path string, records []Record) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return WriteTo(format, file, records)
+}
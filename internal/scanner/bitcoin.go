@@ -0,0 +1,174 @@
+package scanner
+
+import (
+	"crypto/sha256"
+	"regexp"
+	"strings"
+)
+
+// BitcoinAddressFinding is a Bitcoin address found in scanned content, after
+// surviving its format's checksum check. Kind is "base58" (legacy P2PKH/P2SH,
+// starting with '1' or '3') or "bech32" (native SegWit, starting with "bc1").
+type BitcoinAddressFinding struct {
+	Match
+	Address string `json:"address"`
+	Kind    string `json:"kind"`
+}
+
+// base58Candidate and bech32Candidate are deliberately loose about length:
+// they only need to bound the search so bitcoinExtractor doesn't run a
+// checksum on every random alphanumeric run in a chunk. The checksum itself
+// is what actually separates a real address from a look-alike string.
+var (
+	base58Candidate = regexp.MustCompile(`\b[13][a-km-zA-HJ-NP-Z1-9]{25,34}\b`)
+	bech32Candidate = regexp.MustCompile(`(?i)\bbc1[ac-hj-np-z02-9]{11,71}\b`)
+)
+
+type bitcoinExtractor struct{}
+
+// NewBitcoinExtractor returns an Extractor for Bitcoin addresses. Unlike the
+// btc-address Rule in DefaultRuleSet (a plain regex, kept cheap for every
+// chunk of every scan), this extractor checksum-validates every candidate so
+// artifacts.json only reports addresses that are actually well-formed.
+func NewBitcoinExtractor() Extractor { return bitcoinExtractor{} }
+
+func (bitcoinExtractor) Name() string { return "bitcoin" }
+
+func (bitcoinExtractor) Extract(data []byte, baseOffset int64, path string) []Finding {
+	var findings []Finding
+
+	for _, loc := range base58Candidate.FindAllIndex(data, -1) {
+		addr := string(data[loc[0]:loc[1]])
+		if !base58CheckValid(addr) {
+			continue
+		}
+		findings = append(findings, BitcoinAddressFinding{
+			Match:   Match{Path: path, Offset: baseOffset + int64(loc[0])},
+			Address: addr,
+			Kind:    "base58",
+		})
+	}
+
+	for _, loc := range bech32Candidate.FindAllIndex(data, -1) {
+		addr := string(data[loc[0]:loc[1]])
+		if !bech32ChecksumValid(addr) {
+			continue
+		}
+		findings = append(findings, BitcoinAddressFinding{
+			Match:   Match{Path: path, Offset: baseOffset + int64(loc[0])},
+			Address: addr,
+			Kind:    "bech32",
+		})
+	}
+
+	return findings
+}
+
+const base58Alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+// base58CheckValid reports whether addr decodes to a 25-byte payload (1
+// version byte + 20-byte hash + 4-byte checksum) whose checksum matches the
+// first four bytes of the double SHA-256 of the first 21 bytes, i.e. the
+// Base58Check scheme every legacy Bitcoin address uses.
+func base58CheckValid(addr string) bool {
+	decoded := make([]byte, 0, 25)
+	num := make([]byte, 1, 34) // big-endian accumulator, grown as needed
+
+	for _, c := range addr {
+		idx := strings.IndexRune(base58Alphabet, c)
+		if idx < 0 {
+			return false
+		}
+
+		carry := idx
+		for i := len(num) - 1; i >= 0; i-- {
+			carry += int(num[i]) * 58
+			num[i] = byte(carry & 0xff)
+			carry >>= 8
+		}
+		for carry > 0 {
+			num = append([]byte{byte(carry & 0xff)}, num...)
+			carry >>= 8
+		}
+	}
+
+	// Leading '1's in addr encode leading zero bytes that the loop above
+	// never produces (0 * 58 + 0 stays 0), so restore them explicitly.
+	for _, c := range addr {
+		if c != '1' {
+			break
+		}
+		decoded = append(decoded, 0)
+	}
+	decoded = append(decoded, num...)
+
+	if len(decoded) != 25 {
+		return false
+	}
+
+	sum := sha256.Sum256(decoded[:21])
+	sum = sha256.Sum256(sum[:])
+	return string(sum[:4]) == string(decoded[21:])
+}
+
+const bech32Charset = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+
+// bech32ChecksumValid reports whether addr is a syntactically valid bech32
+// string (BIP173) with a checksum that verifies against its "bc" human
+// readable part, i.e. the scheme every native SegWit Bitcoin address uses.
+func bech32ChecksumValid(addr string) bool {
+	lower := strings.ToLower(addr)
+	sep := strings.LastIndexByte(lower, '1')
+	if sep < 1 || sep+7 > len(lower) {
+		return false
+	}
+
+	hrp, data := lower[:sep], lower[sep+1:]
+	if hrp != "bc" {
+		return false
+	}
+
+	values := make([]int, len(data))
+	for i, c := range data {
+		idx := strings.IndexRune(bech32Charset, c)
+		if idx < 0 {
+			return false
+		}
+		values[i] = idx
+	}
+
+	return bech32Polymod(bech32HRPExpand(hrp), values) == 1
+}
+
+// bech32HRPExpand expands a human-readable part into the form bech32Polymod
+// checksums it in, per BIP173.
+func bech32HRPExpand(hrp string) []int {
+	expanded := make([]int, 0, len(hrp)*2+1)
+	for _, c := range hrp {
+		expanded = append(expanded, int(c)>>5)
+	}
+	expanded = append(expanded, 0)
+	for _, c := range hrp {
+		expanded = append(expanded, int(c)&31)
+	}
+	return expanded
+}
+
+// bech32Polymod is the BIP173 checksum polynomial, evaluated over the
+// expanded HRP followed by the address's 5-bit data values (checksum
+// included); a valid string always evaluates to 1.
+func bech32Polymod(hrpExpanded []int, data []int) int {
+	gen := [5]int{0x3b6a57b2, 0x26508e6d, 0x1ea119fa, 0x3d4233dd, 0x2a1462b3}
+	chk := 1
+
+	for _, v := range append(append([]int{}, hrpExpanded...), data...) {
+		top := chk >> 25
+		chk = (chk&0x1ffffff)<<5 ^ v
+		for i := 0; i < 5; i++ {
+			if (top>>i)&1 == 1 {
+				chk ^= gen[i]
+			}
+		}
+	}
+	return chk
+}
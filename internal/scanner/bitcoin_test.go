@@ -0,0 +1,45 @@
+package scanner
+
+import "testing"
+
+func TestBase58CheckValid(t *testing.T) {
+	tests := []struct {
+		name string
+		addr string
+		want bool
+	}{
+		{"genesis block address", "1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa", true},
+		{"corrupted checksum", "1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNb", false},
+		{"invalid base58 character", "1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfN0", false},
+		{"truncated address fails checksum", "1BoatSLRHtKNngkdXEeobR76b53LETtpy", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := base58CheckValid(tt.addr); got != tt.want {
+				t.Errorf("base58CheckValid(%q) = %v, want %v", tt.addr, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBech32ChecksumValid(t *testing.T) {
+	tests := []struct {
+		name string
+		addr string
+		want bool
+	}{
+		{"valid BIP173 test vector", "BC1QW508D6QEJXTDG4Y5R3ZARVARY0C5XW7KV8F3T4", true},
+		{"corrupted checksum", "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t5", false},
+		{"wrong human-readable part", "tb1qw508d6qejxtdg4y5r3zarvary0c5xw7kxpjzsx", false},
+		{"invalid bech32 character", "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kbi0f3t", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := bech32ChecksumValid(tt.addr); got != tt.want {
+				t.Errorf("bech32ChecksumValid(%q) = %v, want %v", tt.addr, got, tt.want)
+			}
+		})
+	}
+}
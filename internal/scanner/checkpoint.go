@@ -0,0 +1,125 @@
+package scanner
+
+import (
+	"bufio"
+	"encoding/json"
+	"hash/fnv"
+	"io"
+	"io/fs"
+	"os"
+	"sync"
+	"time"
+)
+
+// checkpointWindow is how much of a file's content ScanForOnions hashes for
+// each CheckpointEntry. Hashing the whole file would defeat the point of
+// checkpointing a multi-hour scan; a bounded leading window is enough to
+// notice a file that was overwritten in place between runs without a
+// matching size/mtime change.
+const checkpointWindow = 64 * 1024
+
+// CheckpointEntry records one file ScanForOnions finished scanning, so a
+// later run with ScanOptions.Resume can skip it instead of re-scanning.
+// Size and ModTime are what resume actually keys on (cheap: both come from
+// the fs.FileInfo the walk already has in hand); ContentHash is recorded
+// alongside them as a fingerprint a human can use to spot a file that was
+// edited without its mtime changing, but resume does not gate on it.
+type CheckpointEntry struct {
+	Path        string    `json:"path"`
+	Size        int64     `json:"size"`
+	ModTime     time.Time `json:"mtime"`
+	ContentHash uint64    `json:"content_hash"`
+	OnionsFound int       `json:"onions_found"`
+}
+
+// matches reports whether info describes the same file CheckpointEntry was
+// recorded for, closely enough that ScanForOnions can skip re-scanning it.
+func (e CheckpointEntry) matches(info fs.FileInfo) bool {
+	return e.Size == info.Size() && e.ModTime.Equal(info.ModTime())
+}
+
+// Checkpoint is an append-only JSONL log of CheckpointEntry records,
+// written incrementally as ScanForOnions finishes each file so a crash or
+// Ctrl-C partway through a multi-hour scan loses at most the file that was
+// in flight, not everything scanned before it.
+type Checkpoint struct {
+	mu   sync.Mutex
+	file *os.File
+	enc  *json.Encoder
+}
+
+// OpenCheckpoint opens (creating if necessary) the JSONL checkpoint file at
+// path for appending. Existing entries are left in place; call
+// LoadCheckpoint first if you need to resume from them.
+func OpenCheckpoint(path string) (*Checkpoint, error) {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	return &Checkpoint{file: file, enc: json.NewEncoder(file)}, nil
+}
+
+// Append writes entry as the next line of the checkpoint log and flushes it
+// to disk immediately, so it survives a crash in the very next file.
+// Safe for concurrent use by multiple scan workers.
+func (c *Checkpoint) Append(entry CheckpointEntry) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := c.enc.Encode(entry); err != nil {
+		return err
+	}
+	return c.file.Sync()
+}
+
+// Close closes the underlying checkpoint file.
+func (c *Checkpoint) Close() error {
+	return c.file.Close()
+}
+
+// LoadCheckpoint reads an existing JSONL checkpoint file into a map keyed
+// by path, for ScanOptions.Resume to test each walked file against. A
+// missing file is not an error: it just means there's nothing to resume
+// from yet, the same way a first run has no checkpoint. Where a path
+// appears more than once (a previous run re-scanned it, e.g. after a
+// content change invalidated an earlier entry), the last one wins.
+func LoadCheckpoint(path string) (map[string]CheckpointEntry, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return map[string]CheckpointEntry{}, nil
+		}
+		return nil, err
+	}
+	defer file.Close()
+
+	entries := map[string]CheckpointEntry{}
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var entry CheckpointEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			continue // tolerate a truncated last line from a previous crash
+		}
+		entries[entry.Path] = entry
+	}
+	return entries, scanner.Err()
+}
+
+// contentWindowHash hashes up to checkpointWindow leading bytes of the file
+// at path, for recording on its CheckpointEntry. Errors opening or reading
+// the file are not fatal here: the entry is still written with a zero hash
+// rather than losing the scan result itself.
+func contentWindowHash(fsys fs.FS, path string) uint64 {
+	file, err := fsys.Open(path)
+	if err != nil {
+		return 0
+	}
+	defer file.Close()
+
+	h := fnv.New64a()
+	if _, err := io.CopyN(h, file, checkpointWindow); err != nil && err != io.EOF {
+		return 0
+	}
+	return h.Sum64()
+}
@@ -0,0 +1,151 @@
+package scanner
+
+import (
+	"bufio"
+	"hash/fnv"
+	"io"
+	"sync"
+)
+
+// Content-defined chunking parameters. The rolling hash cuts a new chunk
+// whenever its low bits are all zero, which — for a uniformly distributed
+// hash — happens on average every 1<<avgChunkBits bytes. minChunkSize and
+// maxChunkSize bound how small/large a chunk can get regardless of where
+// the rolling hash happens to land.
+const (
+	rollingWindow  = 64              // bytes considered by the rolling hash at any time
+	avgChunkBits   = 20              // targets an average chunk size of 1 MiB (1<<20)
+	minChunkSize   = 256 * 1024      // 256 KiB
+	maxChunkSize   = 4 * 1024 * 1024 // 4 MiB
+	maxPatternLen  = 128             // longest rule pattern we guard against splitting
+	breakpointMask = 1<<avgChunkBits - 1
+)
+
+// buzTable is a fixed pseudo-random byte->uint64 table used by the rolling
+// hash below (a simplified buzhash). It only needs to be well-distributed,
+// not cryptographically secure, and must stay the same across runs so that
+// identical content always produces identical chunk boundaries (and so
+// identical fingerprints) — it's generated once with a fixed seed rather
+// than read from crypto/rand.
+var buzTable = func() [256]uint64 {
+	var table [256]uint64
+	// splitmix64, seeded with a fixed constant purely to spread the table.
+	seed := uint64(0x9e3779b97f4a7c15)
+	for i := range table {
+		seed += 0x9e3779b97f4a7c15
+		z := seed
+		z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+		z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+		z = z ^ (z >> 31)
+		table[i] = z
+	}
+	return table
+}()
+
+func rotl64(x uint64, n uint) uint64 {
+	n %= 64
+	return (x << n) | (x >> (64 - n))
+}
+
+// Chunker splits a stream into content-defined chunks using a rolling hash
+// over a sliding window, instead of fixed-size blocks: identical byte runs
+// (pagefile padding, duplicated registry hives, repeated VSS snapshots)
+// produce the same chunk boundaries wherever they appear, which is what
+// lets ScanForOnions recognize and skip a chunk it has already scanned.
+type Chunker struct {
+	r      *bufio.Reader
+	window [rollingWindow]byte
+	pos    int
+	h      uint64
+
+	overlap []byte // tail of the previous chunk, carried forward to avoid splitting a match
+	done    bool
+}
+
+// NewChunker wraps r for content-defined chunking.
+func NewChunker(r io.Reader) *Chunker {
+	return &Chunker{r: bufio.NewReaderSize(r, maxChunkSize)}
+}
+
+// roll folds the next byte into the rolling hash and returns the updated value.
+func (c *Chunker) roll(b byte) uint64 {
+	old := c.window[c.pos]
+	c.window[c.pos] = b
+	c.pos = (c.pos + 1) % rollingWindow
+
+	c.h = rotl64(c.h, 1) ^ rotl64(buzTable[old], rollingWindow%64) ^ buzTable[b]
+	return c.h
+}
+
+// Next returns the next content-defined chunk and a fingerprint of its
+// contents, or io.EOF once the stream is exhausted. The returned chunk
+// includes up to maxPatternLen bytes carried over from the tail of the
+// previous chunk, so a rule pattern that straddles the cut point is still
+// matched whole in one of the two chunks that see it.
+func (c *Chunker) Next() ([]byte, uint64, error) {
+	if c.done {
+		return nil, 0, io.EOF
+	}
+
+	buf := make([]byte, 0, minChunkSize)
+	buf = append(buf, c.overlap...)
+	c.overlap = nil
+
+	for {
+		b, err := c.r.ReadByte()
+		if err != nil {
+			c.done = true
+			if len(buf) == 0 {
+				return nil, 0, io.EOF
+			}
+			return buf, fingerprint(buf), nil
+		}
+
+		buf = append(buf, b)
+		h := c.roll(b)
+
+		atBreakpoint := len(buf) >= minChunkSize && h&breakpointMask == 0
+		if atBreakpoint || len(buf) >= maxChunkSize {
+			if len(buf) > maxPatternLen {
+				c.overlap = append([]byte{}, buf[len(buf)-maxPatternLen:]...)
+			}
+			return buf, fingerprint(buf), nil
+		}
+	}
+}
+
+// fingerprint hashes a chunk's full contents (not just the rolling window)
+// so that two chunks are only considered identical if every byte matches.
+func fingerprint(chunk []byte) uint64 {
+	h := fnv.New64a()
+	h.Write(chunk)
+	return h.Sum64()
+}
+
+// ChunkFingerprints records the fingerprints of chunks already scanned
+// during a single ScanForOnions run, shared across every file and every
+// worker. Forensic images routinely contain large byte-identical regions —
+// pagefile padding, duplicated VSS snapshots, repeated registry hives — and
+// once one copy has been scanned, re-scanning the others is wasted work.
+type ChunkFingerprints struct {
+	mu   sync.Mutex
+	seen map[uint64]struct{}
+}
+
+// NewChunkFingerprints returns an empty, ready-to-use fingerprint set.
+func NewChunkFingerprints() *ChunkFingerprints {
+	return &ChunkFingerprints{seen: make(map[uint64]struct{})}
+}
+
+// SeenBefore reports whether fp was already recorded by an earlier call,
+// recording it if this is the first time. Safe for concurrent use.
+func (c *ChunkFingerprints) SeenBefore(fp uint64) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.seen[fp]; ok {
+		return true
+	}
+	c.seen[fp] = struct{}{}
+	return false
+}
@@ -0,0 +1,159 @@
+package scanner
+
+import (
+	"encoding/json"
+	"io/fs"
+	"os"
+	"sync"
+)
+
+// Match is embedded by every Finding type: where in the scanned filesystem
+// it was found. Embedding promotes Location(), so every concrete finding
+// type satisfies Finding for free.
+type Match struct {
+	Path   string `json:"path"`
+	Offset int64  `json:"offset"`
+}
+
+// Location implements Finding.
+func (m Match) Location() (path string, offset int64) {
+	return m.Path, m.Offset
+}
+
+// Finding is satisfied by every typed result an Extractor produces
+// (OnionFinding, BitcoinAddressFinding, PGPKeyFinding, XMPPJIDFinding,
+// EmailFinding). Kind-specific detail lives on the concrete type; Finding
+// itself only guarantees a location, since that's all a generic consumer
+// (e.g. WriteFindingsJSON) needs.
+type Finding interface {
+	Location() (path string, offset int64)
+}
+
+// Extractor inspects one content-defined chunk of a file's raw bytes and
+// returns every finding in it. baseOffset is the absolute offset of
+// data[0] within the file at path. Extractors are deliberately given raw
+// bytes rather than a single decoded encoding, so each can decide for
+// itself which representations (ASCII, base64, ...) are worth the cost of
+// decoding — unlike scanner.Rule, which shares one decode per encoding
+// across every rule in a RuleSet.
+type Extractor interface {
+	Name() string
+	Extract(data []byte, baseOffset int64, path string) []Finding
+}
+
+// Extract walks fsys with the same walk limits ScanForOnions applies
+// (depth, symlink and reparse-point handling, excluded directories,
+// MaxFileSize) and opts.Workers workers, and runs every extractor against
+// the streamed, content-defined chunks of every file, returning every
+// finding across the whole scan. It does not apply MaxTotalFiles, Resume,
+// CheckpointPath or SkippedPath. Pass
+// NewOnionExtractor, NewBitcoinExtractor, NewPGPExtractor,
+// NewXMPPExtractor, and NewEmailExtractor for the built-in artifact types;
+// co-locating them in one pass is what lets a caller correlate, say, a
+// wallet address with an onion URL found a few bytes away in the same
+// browser cache file.
+func Extract(fsys fs.FS, opts ScanOptions, extractors ...Extractor) ([]Finding, error) {
+	var (
+		mu       sync.Mutex
+		findings []Finding
+	)
+
+	jobs := make(chan FileJob, 100)
+	fps := NewChunkFingerprints()
+
+	numWorkers := opts.Workers
+	if numWorkers <= 0 {
+		numWorkers = NumWorkers
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < numWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				fileFindings := extractFile(fsys, job.Path, extractors, fps)
+
+				mu.Lock()
+				findings = append(findings, fileFindings...)
+				mu.Unlock()
+			}
+		}()
+	}
+
+	err := walk(fsys, ".", opts, func(p string, info fs.FileInfo, depth int) error {
+		if opts.isExcludedPath(p) {
+			if info.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if info.IsDir() {
+			return nil
+		}
+		if opts.MaxFileSize > 0 && info.Size() > opts.MaxFileSize {
+			return nil
+		}
+
+		jobs <- FileJob{Path: p, Info: info}
+		return nil
+	}, nil)
+
+	close(jobs)
+	wg.Wait()
+
+	return findings, err
+}
+
+// extractFile runs every extractor over one file's content-defined chunks.
+// fps is shared across the whole Extract run, so a chunk byte-identical to
+// one already processed elsewhere is skipped, same tradeoff scanFileChunked
+// makes for rule hits.
+func extractFile(fsys fs.FS, path string, extractors []Extractor, fps *ChunkFingerprints) []Finding {
+	file, err := fsys.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+
+	var findings []Finding
+	chunker := NewChunker(file)
+	var consumed int64
+
+	for {
+		chunk, fp, err := chunker.Next()
+		if err != nil {
+			break
+		}
+
+		// Every chunk after the first starts with the maxPatternLen bytes
+		// carried over from the end of the one before it.
+		baseOffset := consumed
+		consumed += int64(len(chunk)) - maxPatternLen
+
+		if fps.SeenBefore(fp) {
+			continue
+		}
+
+		for _, ex := range extractors {
+			findings = append(findings, ex.Extract(chunk, baseOffset, path)...)
+		}
+	}
+
+	return findings
+}
+
+// WriteFindingsJSON writes findings as a single indented JSON array (e.g.
+// artifacts.json), each element carrying whatever kind-specific fields its
+// concrete Finding type has, plus the common "path"/"offset" pair.
+func WriteFindingsJSON(path string, findings []Finding) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	enc := json.NewEncoder(file)
+	enc.SetIndent("", "  ")
+	return enc.Encode(findings)
+}
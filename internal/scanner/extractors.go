@@ -0,0 +1,199 @@
+package scanner
+
+import (
+	"bytes"
+	"regexp"
+
+	"golang.org/x/crypto/openpgp"
+	"golang.org/x/crypto/openpgp/armor"
+)
+
+// OnionFinding is a Tor v3 onion address found by an Extractor pass. It
+// duplicates the coverage of the tor-onion-v3 Rule in DefaultRuleSet, but
+// as a typed Finding rather than a generic model.Hit, so it can be
+// correlated (same path, nearby offset) with the other artifact types
+// Extract emits in the same pass.
+type OnionFinding struct {
+	Match
+	Address string `json:"address"`
+}
+
+// onionExtractor finds Tor v3 .onion addresses, filtering the same
+// known-generic entry points DefaultRuleSet's tor-onion-v3 rule does.
+type onionExtractor struct {
+	pattern *regexp.Regexp
+}
+
+// NewOnionExtractor returns an Extractor for Tor v3 .onion addresses.
+func NewOnionExtractor() Extractor {
+	return &onionExtractor{pattern: regexp.MustCompile(`(?i)[a-z2-7]{56}\.onion`)}
+}
+
+func (e *onionExtractor) Name() string { return "onion" }
+
+func (e *onionExtractor) Extract(data []byte, baseOffset int64, path string) []Finding {
+	var findings []Finding
+	for _, loc := range e.pattern.FindAllIndex(data, -1) {
+		addr := string(data[loc[0]:loc[1]])
+		if knownGenericOnions[toLowerASCII(addr)] {
+			continue
+		}
+		findings = append(findings, OnionFinding{
+			Match:   Match{Path: path, Offset: baseOffset + int64(loc[0])},
+			Address: addr,
+		})
+	}
+	return findings
+}
+
+// toLowerASCII lowercases addr without pulling in strings.ToLower's full
+// Unicode case folding, which onion addresses (base32, ASCII-only) never need.
+func toLowerASCII(s string) string {
+	b := []byte(s)
+	for i, c := range b {
+		if c >= 'A' && c <= 'Z' {
+			b[i] = c + ('a' - 'A')
+		}
+	}
+	return string(b)
+}
+
+// EmailFinding is an email address found co-located with other artifacts.
+type EmailFinding struct {
+	Match
+	Address string `json:"address"`
+}
+
+// emailPattern is intentionally simple (no full RFC 5322 grammar): forensic
+// scanning cares about catching real-world addresses embedded in chat logs,
+// browser caches, and config files, not validating edge cases like quoted
+// local parts.
+var emailPattern = regexp.MustCompile(`\b[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}\b`)
+
+type emailExtractor struct{}
+
+// NewEmailExtractor returns an Extractor for plain email addresses.
+func NewEmailExtractor() Extractor { return emailExtractor{} }
+
+func (emailExtractor) Name() string { return "email" }
+
+func (emailExtractor) Extract(data []byte, baseOffset int64, path string) []Finding {
+	var findings []Finding
+	for _, loc := range emailPattern.FindAllIndex(data, -1) {
+		findings = append(findings, EmailFinding{
+			Match:   Match{Path: path, Offset: baseOffset + int64(loc[0])},
+			Address: string(data[loc[0]:loc[1]]),
+		})
+	}
+	return findings
+}
+
+// XMPPJIDFinding is an XMPP Jabber ID found co-located with other artifacts.
+type XMPPJIDFinding struct {
+	Match
+	JID string `json:"jid"`
+}
+
+// xmppPattern matches the two shapes a JID reliably shows up in that a bare
+// email address doesn't: an explicit "xmpp:" URI scheme, or a bare
+// local@domain with a /resource part. Without one of those two signals, a
+// local@domain string is indistinguishable from an email address, so it's
+// left to emailExtractor instead.
+var xmppPattern = regexp.MustCompile(`\b(?:xmpp:)?[a-zA-Z0-9._-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}(?:/[^\s"'<>]+)?\b`)
+
+type xmppExtractor struct{}
+
+// NewXMPPExtractor returns an Extractor for XMPP Jabber IDs.
+func NewXMPPExtractor() Extractor { return xmppExtractor{} }
+
+func (xmppExtractor) Name() string { return "xmpp" }
+
+func (xmppExtractor) Extract(data []byte, baseOffset int64, path string) []Finding {
+	var findings []Finding
+	for _, loc := range xmppPattern.FindAllIndex(data, -1) {
+		jid := string(data[loc[0]:loc[1]])
+		if !bytes.HasPrefix(data[loc[0]:loc[1]], []byte("xmpp:")) && !bytes.ContainsRune(data[loc[0]:loc[1]], '/') {
+			continue // plain local@domain, no JID-specific signal: leave it to emailExtractor
+		}
+		findings = append(findings, XMPPJIDFinding{
+			Match: Match{Path: path, Offset: baseOffset + int64(loc[0])},
+			JID:   jid,
+		})
+	}
+	return findings
+}
+
+// PGPKeyFinding is an armored PGP block found in scanned content. BlockType
+// is one of "PUBLIC KEY BLOCK", "PRIVATE KEY BLOCK", "MESSAGE", or
+// "SIGNATURE"; ShortKeyID, LongKeyID, and UIDs are only populated for the
+// two key-block types, where openpgp can actually parse out an identity.
+type PGPKeyFinding struct {
+	Match
+	BlockType  string   `json:"block_type"`
+	ShortKeyID string   `json:"short_key_id,omitempty"`
+	LongKeyID  string   `json:"long_key_id,omitempty"`
+	UIDs       []string `json:"uids,omitempty"`
+}
+
+// pgpBlockPattern finds the start of every armored PGP block, mirroring the
+// pgp-block Rule in DefaultRuleSet.
+var pgpBlockPattern = regexp.MustCompile(`-----BEGIN PGP (MESSAGE|PUBLIC KEY BLOCK|PRIVATE KEY BLOCK|SIGNATURE)-----`)
+
+type pgpExtractor struct{}
+
+// NewPGPExtractor returns an Extractor for armored PGP blocks. Key and
+// private-key blocks are parsed with golang.org/x/crypto/openpgp to recover
+// key IDs and UIDs; message and signature blocks are recorded by location
+// only, since they carry no identity of their own.
+func NewPGPExtractor() Extractor { return pgpExtractor{} }
+
+func (pgpExtractor) Name() string { return "pgp" }
+
+func (pgpExtractor) Extract(data []byte, baseOffset int64, path string) []Finding {
+	var findings []Finding
+	for _, loc := range pgpBlockPattern.FindAllSubmatchIndex(data, -1) {
+		blockType := string(data[loc[2]:loc[3]])
+		finding := PGPKeyFinding{
+			Match:     Match{Path: path, Offset: baseOffset + int64(loc[0])},
+			BlockType: blockType,
+		}
+
+		if blockType == "PUBLIC KEY BLOCK" || blockType == "PRIVATE KEY BLOCK" {
+			populateKeyIdentity(&finding, data[loc[0]:])
+		}
+
+		findings = append(findings, finding)
+	}
+	return findings
+}
+
+// populateKeyIdentity parses the armored block starting at block (which may
+// run well past the end of the key, into whatever content follows it in the
+// chunk) and fills in f's key IDs and UIDs from its first entity. Parse
+// failures (truncated block, chunk boundary split it in half) are not
+// reported as errors: the block's location was already recorded by the
+// caller. A block cut by a chunk boundary is parsed whole only if it began
+// within the maxPatternLen bytes carried into the next chunk; otherwise
+// its finding has no key IDs or UIDs.
+func populateKeyIdentity(f *PGPKeyFinding, block []byte) {
+	b, err := armor.Decode(bytes.NewReader(block))
+	if err != nil {
+		return
+	}
+
+	entities, err := openpgp.ReadKeyRing(b.Body)
+	if err != nil || len(entities) == 0 {
+		return
+	}
+
+	primary := entities[0].PrimaryKey
+	if primary == nil {
+		return
+	}
+	f.LongKeyID = primary.KeyIdString()
+	f.ShortKeyID = primary.KeyIdShortString()
+
+	for _, ident := range entities[0].Identities {
+		f.UIDs = append(f.UIDs, ident.Name)
+	}
+}
@@ -0,0 +1,22 @@
+//go:build !windows
+
+package scanner
+
+import (
+	"fmt"
+	"io/fs"
+	"syscall"
+)
+
+// fileIdentity returns the (device, inode) pair stat(2) reports for info,
+// which stays the same across every path that names the same underlying
+// file — the basis walk uses to detect symlink cycles. p is unused on this
+// platform but kept in the signature so fileid_windows.go can fall back to
+// a path-based identity instead.
+func fileIdentity(p string, info fs.FileInfo) (string, bool) {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return "", false
+	}
+	return fmt.Sprintf("%d:%d", stat.Dev, stat.Ino), true
+}
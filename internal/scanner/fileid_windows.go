@@ -0,0 +1,19 @@
+//go:build windows
+
+package scanner
+
+import (
+	"io/fs"
+	"path"
+	"strings"
+)
+
+// fileIdentity has no portable (device, inode) equivalent reachable through
+// io/fs on Windows, so cycle detection falls back to the canonicalized
+// path itself. This still catches the common case — a junction or symlink
+// pointing at one of its own ancestors — but won't catch two different
+// paths (e.g. a drive letter and a mount point) that resolve to the same
+// underlying volume.
+func fileIdentity(p string, info fs.FileInfo) (string, bool) {
+	return strings.ToLower(path.Clean(p)), true
+}
@@ -0,0 +1,421 @@
+package scanner
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"unicode/utf16"
+
+	"gopkg.in/yaml.v3"
+)
+
+// RuleTorOnionV3 is the Name of the built-in Tor v3 onion rule. Callers that
+// still only care about onions (e.g. internal.WriteOnionReport, whose
+// onion.txt tor.CheckOnions reads) filter DefaultRuleSet's hits down to
+// this rule name.
+const RuleTorOnionV3 = "tor-onion-v3"
+
+// Encoding identifies the byte-level transform a rule's patterns should be
+// matched against. A chunk is transformed once per encoding present in the
+// active RuleSet (not once per rule), so adding more rules doesn't add more
+// passes over the file.
+type Encoding string
+
+const (
+	EncodingASCII   Encoding = "ascii"   // raw bytes, as-is
+	EncodingUTF16LE Encoding = "utf16le" // little-endian UTF-16, decoded to UTF-8 text
+	EncodingUTF16BE Encoding = "utf16be" // big-endian UTF-16, decoded to UTF-8 text
+	EncodingBase64  Encoding = "base64"  // base64-wrapped text, decoded before matching
+)
+
+// Condition controls how many of a YARA-style rule's $strings must be
+// present for the rule to fire. This mirrors the two conditions we
+// actually need ("any of them" / "all of them"); arbitrary boolean YARA
+// conditions are out of scope.
+type Condition string
+
+const (
+	ConditionAny Condition = "any"
+	ConditionAll Condition = "all"
+)
+
+// NamedString is one `$name = "value"` entry in a YARA-style rule.
+type NamedString struct {
+	Name  string
+	Value string
+}
+
+// Rule is one named pattern the scanner looks for. A rule is either:
+//   - a plain regex/literal rule (Pattern set, Strings nil), or
+//   - a short YARA-like rule (Strings set, matched per Condition)
+//
+// Either way it can be evaluated against one or more Encodings, and can
+// carry its own DenyList of known-benign values to filter out (mirroring
+// the old hard-coded knownGenericOnions list, now per-rule instead of
+// onion-only).
+type Rule struct {
+	Name      string
+	Pattern   *regexp.Regexp
+	Strings   []NamedString
+	Condition Condition
+	Encodings []Encoding
+	DenyList  map[string]bool
+}
+
+// compiledString is a NamedString with its regex already built, so matching
+// it against a chunk doesn't recompile the pattern per file.
+type compiledString struct {
+	name string
+	re   *regexp.Regexp
+}
+
+// RuleSet is the set of rules evaluated against every scanned chunk.
+type RuleSet struct {
+	Rules []*Rule
+
+	// compiled caches the per-rule compiled $strings, built once in Compile.
+	compiled map[*Rule][]compiledString
+}
+
+// isDenied reports whether value is on rule's own deny-list.
+func (r *Rule) isDenied(value string) bool {
+	if r.DenyList == nil {
+		return false
+	}
+	return r.DenyList[strings.ToLower(value)]
+}
+
+// encodingsOrDefault returns the rule's declared encodings, defaulting to
+// ASCII-only when none were specified.
+func (r *Rule) encodingsOrDefault() []Encoding {
+	if len(r.Encodings) == 0 {
+		return []Encoding{EncodingASCII}
+	}
+	return r.Encodings
+}
+
+// Compile prepares rs for scanning: it compiles every YARA-style rule's
+// $strings into regexes once, rather than on every chunk. Call it once
+// after building or loading a RuleSet and before passing it to scanChunk.
+func (rs *RuleSet) Compile() error {
+	rs.compiled = make(map[*Rule][]compiledString, len(rs.Rules))
+
+	for _, rule := range rs.Rules {
+		if rule.Condition == "" {
+			rule.Condition = ConditionAny
+		}
+
+		if len(rule.Strings) == 0 {
+			continue
+		}
+
+		compiledStrings := make([]compiledString, 0, len(rule.Strings))
+		for _, s := range rule.Strings {
+			re, err := regexp.Compile(s.Value)
+			if err != nil {
+				return fmt.Errorf("rule %q: compiling $%s: %w", rule.Name, s.Name, err)
+			}
+			compiledStrings = append(compiledStrings, compiledString{name: s.Name, re: re})
+		}
+		rs.compiled[rule] = compiledStrings
+	}
+
+	return nil
+}
+
+// encodingsInUse returns the distinct set of encodings any rule in rs
+// declares, so the caller can transform a chunk exactly once per encoding.
+func (rs *RuleSet) encodingsInUse() []Encoding {
+	seen := map[Encoding]bool{}
+	var encs []Encoding
+	for _, rule := range rs.Rules {
+		for _, enc := range rule.encodingsOrDefault() {
+			if !seen[enc] {
+				seen[enc] = true
+				encs = append(encs, enc)
+			}
+		}
+	}
+	return encs
+}
+
+// transform decodes data under the given encoding into matchable text.
+// ASCII is returned unchanged (as a string conversion of the raw bytes, so
+// regexes still see binary-embedded matches); the others decode to UTF-8.
+func transform(data []byte, enc Encoding) string {
+	switch enc {
+	case EncodingUTF16LE:
+		return decodeUTF16LE(data)
+	case EncodingUTF16BE:
+		return decodeUTF16BE(data)
+	case EncodingBase64:
+		return decodeBase64Blocks(data)
+	default:
+		return string(data)
+	}
+}
+
+// decodeUTF16BE decodes big-endian UTF-16 byte data into a UTF-8 string.
+func decodeUTF16BE(data []byte) string {
+	if len(data)%2 != 0 {
+		data = data[:len(data)-1]
+	}
+	u16 := make([]uint16, len(data)/2)
+	for i := range u16 {
+		u16[i] = uint16(data[i*2])<<8 | uint16(data[i*2+1])
+	}
+	return string(utf16.Decode(u16))
+}
+
+// base64Chars matches runs of base64 alphabet characters long enough to be
+// worth attempting to decode (short runs are almost always coincidental).
+var base64Chars = regexp.MustCompile(`[A-Za-z0-9+/]{24,}={0,2}`)
+
+// decodeBase64Blocks finds base64-looking runs in data and decodes each,
+// concatenating the successfully-decoded plaintext (space separated) for
+// rules to match against. Chunks that fail to decode are skipped.
+func decodeBase64Blocks(data []byte) string {
+	var out strings.Builder
+	for _, block := range base64Chars.FindAll(data, -1) {
+		decoded, err := base64.StdEncoding.DecodeString(string(block))
+		if err != nil {
+			continue
+		}
+		out.Write(decoded)
+		out.WriteByte(' ')
+	}
+	return out.String()
+}
+
+// evalRule runs one rule against text (already decoded for the rule's
+// encoding) and returns every matched value.
+func (rs *RuleSet) evalRule(rule *Rule, text string) []string {
+	if rule.Pattern != nil {
+		return rule.Pattern.FindAllString(text, -1)
+	}
+
+	strs := rs.compiled[rule]
+	if len(strs) == 0 {
+		return nil
+	}
+
+	var matchedNames int
+	var values []string
+	for _, cs := range strs {
+		m := cs.re.FindAllString(text, -1)
+		if len(m) > 0 {
+			matchedNames++
+			values = append(values, m...)
+		}
+	}
+
+	switch rule.Condition {
+	case ConditionAll:
+		if matchedNames < len(strs) {
+			return nil
+		}
+	default: // ConditionAny
+		if matchedNames == 0 {
+			return nil
+		}
+	}
+	return values
+}
+
+// DefaultRuleSet returns the built-in rules: Tor v3 onions, I2P .b32.i2p
+// addresses, BTC/ETH/Monero addresses, PGP block headers, and common
+// credential tokens. Callers load extra rules on top of this with
+// LoadRulesFromFile.
+func DefaultRuleSet() *RuleSet {
+	rs := &RuleSet{
+		Rules: []*Rule{
+			{
+				Name:      RuleTorOnionV3,
+				Pattern:   regexp.MustCompile(`(?i)[a-z2-7]{56}\.onion`),
+				Encodings: []Encoding{EncodingASCII, EncodingUTF16LE},
+				DenyList:  knownGenericOnions,
+			},
+			{
+				Name:      "i2p-b32",
+				Pattern:   regexp.MustCompile(`(?i)[a-z2-7]{52}\.b32\.i2p`),
+				Encodings: []Encoding{EncodingASCII, EncodingUTF16LE},
+			},
+			{
+				Name:      "btc-address",
+				Pattern:   regexp.MustCompile(`\b(bc1[a-z0-9]{25,39}|[13][a-km-zA-HJ-NP-Z1-9]{25,34})\b`),
+				Encodings: []Encoding{EncodingASCII},
+			},
+			{
+				Name:      "eth-address",
+				Pattern:   regexp.MustCompile(`\b0x[a-fA-F0-9]{40}\b`),
+				Encodings: []Encoding{EncodingASCII},
+			},
+			{
+				Name:      "monero-address",
+				Pattern:   regexp.MustCompile(`\b4[0-9AB][1-9A-HJ-NP-Za-km-z]{93}\b`),
+				Encodings: []Encoding{EncodingASCII},
+			},
+			{
+				Name:      "pgp-block",
+				Pattern:   regexp.MustCompile(`-----BEGIN PGP (MESSAGE|PUBLIC KEY BLOCK|PRIVATE KEY BLOCK|SIGNATURE)-----`),
+				Encodings: []Encoding{EncodingASCII},
+			},
+			{
+				Name: "credential-token",
+				Strings: []NamedString{
+					{Name: "aws_key", Value: `\bAKIA[0-9A-Z]{16}\b`},
+					{Name: "generic_secret", Value: `(?i)(password|passwd|secret|api[_-]?key)\s*[:=]\s*['"][^'"\s]{8,}['"]`},
+				},
+				Condition: ConditionAny,
+				Encodings: []Encoding{EncodingASCII, EncodingBase64},
+			},
+		},
+	}
+
+	if err := rs.Compile(); err != nil {
+		// Built-in patterns are controlled by us and covered by tests;
+		// a compile failure here means a programming error, not bad input.
+		panic(fmt.Sprintf("scanner: built-in rule set failed to compile: %v", err))
+	}
+	return rs
+}
+
+// yamlRuleFile is the on-disk shape accepted by LoadRulesFromFile for .yaml/.yml rule files.
+type yamlRuleFile struct {
+	Rules []struct {
+		Name      string            `yaml:"name"`
+		Pattern   string            `yaml:"pattern"`
+		Strings   map[string]string `yaml:"strings"`
+		Condition string            `yaml:"condition"`
+		Encodings []string          `yaml:"encodings"`
+		DenyList  []string          `yaml:"deny_list"`
+	} `yaml:"rules"`
+}
+
+// LoadRulesFromFile loads additional rules from a YAML rule file (.yaml,
+// .yml) or a short YARA-like rule file (.yar, .yara) and merges them into
+// base. The returned RuleSet is already Compile()-d.
+func LoadRulesFromFile(base *RuleSet, path string) (*RuleSet, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("loading rule file %s: %w", path, err)
+	}
+
+	var extra []*Rule
+	lower := strings.ToLower(path)
+	switch {
+	case strings.HasSuffix(lower, ".yar"), strings.HasSuffix(lower, ".yara"):
+		extra, err = parseYARARules(data)
+	default:
+		extra, err = parseYAMLRules(data)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parsing rule file %s: %w", path, err)
+	}
+
+	merged := &RuleSet{Rules: append(append([]*Rule{}, base.Rules...), extra...)}
+	if err := merged.Compile(); err != nil {
+		return nil, err
+	}
+	return merged, nil
+}
+
+func parseYAMLRules(data []byte) ([]*Rule, error) {
+	var file yamlRuleFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, err
+	}
+
+	rules := make([]*Rule, 0, len(file.Rules))
+	for _, r := range file.Rules {
+		rule := &Rule{Name: r.Name, Condition: Condition(r.Condition)}
+
+		if r.Pattern != "" {
+			re, err := regexp.Compile(r.Pattern)
+			if err != nil {
+				return nil, fmt.Errorf("rule %q: %w", r.Name, err)
+			}
+			rule.Pattern = re
+		}
+
+		for name, value := range r.Strings {
+			rule.Strings = append(rule.Strings, NamedString{Name: name, Value: value})
+		}
+
+		for _, enc := range r.Encodings {
+			rule.Encodings = append(rule.Encodings, Encoding(enc))
+		}
+
+		if len(r.DenyList) > 0 {
+			rule.DenyList = make(map[string]bool, len(r.DenyList))
+			for _, v := range r.DenyList {
+				rule.DenyList[strings.ToLower(v)] = true
+			}
+		}
+
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// parseYARARules understands a deliberately small subset of YARA syntax:
+//
+//	rule name {
+//	    strings:
+//	        $a = "some value"
+//	    condition:
+//	        any of them
+//	}
+//
+// Multiple rules per file are supported; regex strings (`$a = /re/`),
+// hex strings, and full boolean conditions are not.
+func parseYARARules(data []byte) ([]*Rule, error) {
+	var rules []*Rule
+	var current *Rule
+	inStrings := false
+
+	ruleHeader := regexp.MustCompile(`^rule\s+(\w+)`)
+	stringLine := regexp.MustCompile(`^\$(\w+)\s*=\s*"(.*)"\s*$`)
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		switch {
+		case ruleHeader.MatchString(line):
+			if current != nil {
+				rules = append(rules, current)
+			}
+			current = &Rule{Name: ruleHeader.FindStringSubmatch(line)[1], Condition: ConditionAny}
+			inStrings = false
+
+		case line == "strings:":
+			inStrings = true
+
+		case line == "condition:":
+			inStrings = false
+
+		case current != nil && strings.HasPrefix(line, "any of"):
+			current.Condition = ConditionAny
+
+		case current != nil && strings.HasPrefix(line, "all of"):
+			current.Condition = ConditionAll
+
+		case current != nil && inStrings && stringLine.MatchString(line):
+			m := stringLine.FindStringSubmatch(line)
+			current.Strings = append(current.Strings, NamedString{
+				Name:  m[1],
+				Value: regexp.QuoteMeta(m[2]),
+			})
+		}
+	}
+	if current != nil {
+		rules = append(rules, current)
+	}
+
+	return rules, scanner.Err()
+}
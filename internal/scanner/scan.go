@@ -1,11 +1,10 @@
 package scanner
 
 import (
-	"bytes"
 	"fmt"
+	"io/fs"
 	"os"
-	"path/filepath"
-	"regexp"
+	"path"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -15,22 +14,16 @@ import (
 	"onion-finder/internal/model"
 )
 
-// ChunkSize: size (in bytes) of each block read when scanning large files
-// NumWorkers: number of concurrent goroutines processing files. Set to 16 based on Arsenal Image Mounter decompression thread limits
-// ChunkOverlap: number of bytes reused between chunks to avoid cutting onion strings across chunk boundaries
-const (
-	ChunkSize    = 1024 * 1024 // 1 MB per chunk
-	NumWorkers   = 16          // parallel workers (optimal for E01 decompression)
-	ChunkOverlap = 128         // safety overlap between chunks
-)
+// NumWorkers: number of concurrent goroutines processing files. Set to 16
+// based on Arsenal Image Mounter decompression thread limits. Chunk
+// boundaries themselves are content-defined; see chunker.go.
+const NumWorkers = 16
 
 var activeWorkers int32
 var maxActiveWorkers int32
 
-// onionRegex matches Tor v3 onion addresses
-var onionRegex = regexp.MustCompile(`(?i)[a-z2-7]{56,}\.onion`)
-
-// These onions are excluded to avoid false positives in forensic analysis
+// knownGenericOnions are excluded from the built-in tor-onion-v3 rule to
+// avoid false positives in forensic analysis (see DefaultRuleSet).
 var knownGenericOnions = map[string]bool{
 	"duckduckgogg42xjoc72x3sjasowoarfbgcmvfimaftt6twagswzczad.onion": true,
 	"reddittorjg6rue252oqsxryoxengawnmo46qy4kyii5wtqnwfj4ooad.onion": true,
@@ -39,16 +32,10 @@ var knownGenericOnions = map[string]bool{
 }
 
 // FileJob represents a unit of work processed by a worker
-// Each job corresponds to one file on disk that needs to be scanned
+// Each job corresponds to one file (within the scanned fs.FS) that needs to be scanned
 type FileJob struct {
-	Path string      // full filesystem path to the file
-	Info os.FileInfo // file metadata (size, permissions, modification time, etc)
-}
-
-// isGenericOnion checks whether an onion address belongs to the known public/generic deny-list
-func isGenericOnion(value string) bool {
-	value = strings.ToLower(value)
-	return knownGenericOnions[value]
+	Path string      // path of the file, relative to the scanned fs.FS root
+	Info fs.FileInfo // file metadata (size, permissions, modification time, etc)
 }
 
 // updateMax updates the maximum value reached by activeWorkers
@@ -66,235 +53,125 @@ func updateMax(current int32) {
 
 /*
 ====================================================
- Exclusion helpers
+ File scanning logic
 ====================================================
 */
 
-// buildExcludedPaths returns system directories that should not be scanned during forensic analysis
-// These directories typically contain OS files with no user activity
-func buildExcludedPaths(mountRoot string) []string {
-	mountRoot = filepath.Clean(mountRoot)
-
-	return []string{
-		filepath.Join(mountRoot, "Windows"),
-		filepath.Join(mountRoot, "Program Files"),
-		filepath.Join(mountRoot, "Program Files (x86)"),
-		filepath.Join(mountRoot, "PerfLogs"),
-	}
+// hitKey uniquely identifies a hit for within-file deduplication: the same
+// rule can legitimately fire on the same value in different files, but
+// repeating it for every chunk of the same file (e.g. because it also
+// showed up in a chunk's overlap region) would just be noise.
+func hitKey(h model.Hit) string {
+	return h.RuleName + "|" + h.Value
 }
 
-// isExcludedPath checks whether a path is under an excluded directory
-func isExcludedPath(path string, excluded []string) bool {
-	path = strings.ToLower(filepath.Clean(path))
-
-	for _, excl := range excluded {
-		excl = strings.ToLower(filepath.Clean(excl))
-		if strings.HasPrefix(path, excl) {
-			return true
-		}
-	}
-	return false
-}
-
-/*
-====================================================
- File scanning logic
-====================================================
-*/
-
-// scanFile scans a single file for onion addresses.
-// It detects encoding (UTF-16LE vs others) and chooses
-// the appropriate scanning strategy to avoid missing matches.
-func scanFile(path string) []model.Onion {
-	results := []model.Onion{}
+// scanFile scans a single file against every rule in rs. fps tracks chunk
+// fingerprints across the whole scan (not just this file), so a chunk
+// byte-identical to one already scanned elsewhere in the image is skipped.
+// skipped, if non-nil, records an Open failure (most commonly permission
+// denied) instead of it silently vanishing from the results.
+func scanFile(fsys fs.FS, filePath string, rs *RuleSet, fps *ChunkFingerprints, skipped *skippedLog) []model.Hit {
+	hits := []model.Hit{}
 	seen := make(map[string]bool) // local deduplication within this file
 
-	file, err := os.Open(path)
+	file, err := fsys.Open(filePath)
 	if err != nil {
-		return results // silently skip unreadable files
+		if skipped != nil {
+			skipped.record(filePath, err)
+		}
+		return hits // skip unreadable files, the walk keeps going
 	}
 	defer file.Close()
 
-	// Read a small sample to detect encoding
-	firstChunk := make([]byte, 4096)
-	n, _ := file.Read(firstChunk)
-	if n == 0 {
-		return results // empty file
-	}
-	file.Seek(0, 0) // reset to beginning
-
-	isUTF16LE := detectUTF16LE(firstChunk[:n])
-
-	if isUTF16LE {
-		// UTF-16LE text file: decode entire file and search as string
-		data, err := os.ReadFile(path)
-		if err != nil {
-			return results
+	for _, h := range scanFileChunked(file, rs, fps) {
+		h.Path = filePath
+		if key := hitKey(h); !seen[key] {
+			seen[key] = true
+			hits = append(hits, h)
 		}
-
-		content := decodeUTF16LE(data)
-		matches := onionRegex.FindAllString(content, -1)
-
-		for _, match := range matches {
-			value := strings.ToLower(match)
-
-			if isGenericOnion(value) {
-				continue // skip known generic onions
-			}
-
-			if !seen[value] {
-				seen[value] = true
-				results = append(results, model.Onion{
-					Value: value,
-					Path:  path,
-				})
-			}
-		}
-	} else {
-		// Binary or large file â†’ use chunked scanning with overlap
-		results = scanFileChunked(file, path)
 	}
 
-	return results
+	return hits
 }
 
-// scanFileChunked scans files incrementally using overlapping chunks
-// to handle large and binary files efficiently without loading entire file in memory.
-// Overlap prevents splitting onion addresses across chunk boundaries.
-func scanFileChunked(file *os.File, path string) []model.Onion {
-	results := []model.Onion{}
-	seen := make(map[string]bool)
+// scanFileChunked scans a file using content-defined chunks (see Chunker),
+// so large and binary files are handled without loading the whole file
+// into memory, and chunks already seen elsewhere in this scan are skipped
+// outright. Path is left unset on returned hits; the caller fills it in.
+func scanFileChunked(file fs.File, rs *RuleSet, fps *ChunkFingerprints) []model.Hit {
+	var hits []model.Hit
 
-	buffer := make([]byte, ChunkSize+ChunkOverlap)
-	overlap := make([]byte, 0)
+	chunker := NewChunker(file)
+	// consumed is the absolute offset of the next chunk's first byte: each
+	// chunk after the first starts with the maxPatternLen overlap bytes
+	// repeated from the end of the one before it (a cut chunk is always at
+	// least minChunkSize long, so the full overlap is carried).
+	var consumed int64
 
 	for {
-		// Read next chunk, appending after overlap bytes
-		n, err := file.Read(buffer[len(overlap):])
-		if n == 0 {
-			break // end of file
-		}
-
-		// Prepend overlap from previous chunk
-		copy(buffer, overlap)
-		totalLen := len(overlap) + n
-
-		// Scan this chunk for onion addresses
-		matches := scanChunk(buffer[:totalLen])
-		for _, match := range matches {
-			value := strings.ToLower(match)
-
-			if isGenericOnion(value) {
-				continue
-			}
-
-			if !seen[value] {
-				seen[value] = true
-				results = append(results, model.Onion{
-					Value: value,
-					Path:  path,
-				})
-			}
-		}
-
+		chunk, fp, err := chunker.Next()
 		if err != nil {
 			break // read error or EOF
 		}
 
-		// Preserve overlap for next chunk to avoid splitting onion addresses
-		if totalLen > ChunkOverlap {
-			overlap = make([]byte, ChunkOverlap)
-			copy(overlap, buffer[totalLen-ChunkOverlap:totalLen])
-		}
-	}
+		baseOffset := consumed
+		consumed += int64(len(chunk)) - maxPatternLen
 
-	return results
-}
+		if fps.SeenBefore(fp) {
+			continue // identical chunk already scanned earlier in this run
+		}
 
-// scanChunk extracts onion addresses from a raw byte slice.
-// Uses two strategies:
-// 1. Direct regex on raw bytes (catches clean text)
-// 2. Extract valid onion chars only, then regex (catches binary-embedded onions)
-func scanChunk(data []byte) []string {
-	results := []string{}
-
-	// Strategy 1: Direct regex on raw bytes
-	matches := onionRegex.FindAll(data, -1)
-	for _, match := range matches {
-		results = append(results, string(match))
+		hits = append(hits, scanChunk(chunk, rs, baseOffset)...)
 	}
 
-	// Strategy 2: Extract only valid onion characters (useful for binary blobs)
-	cleaned := extractOnionChars(data)
-	matches2 := onionRegex.FindAllString(cleaned, -1)
-	results = append(results, matches2...)
-
-	return results
+	return hits
 }
 
-// extractOnionChars filters a byte stream to retain only characters
-// valid in onion addresses ([a-z2-7.]), replacing others with spaces.
-// This helps extract onions embedded in binary data or mixed encodings.
-func extractOnionChars(data []byte) string {
-	var buf bytes.Buffer
-	buf.Grow(len(data) / 2) // preallocate buffer
-
-	for _, b := range data {
-		if b == 0x00 {
-			continue // skip null bytes
-		}
-
-		if isValidOnionChar(b) {
-			// Normalize uppercase to lowercase
-			if b >= 'A' && b <= 'Z' {
-				b += 32
-			}
-			buf.WriteByte(b)
-		} else {
-			// Replace invalid chars with space (word separator)
-			if buf.Len() > 0 && buf.Bytes()[buf.Len()-1] != ' ' {
-				buf.WriteByte(' ')
-			}
-		}
+// scanChunk runs every rule in rs against a raw byte slice. Each encoding a
+// rule declares is decoded from data at most once per chunk (not once per
+// rule), so adding more rules to rs doesn't add more passes over the bytes.
+// baseOffset is the absolute file offset of data[0], used to report Offset
+// on each hit; this is exact for rules scanned in ASCII (the common case)
+// and approximate (chunk-granularity) for the other encodings, since they
+// decode the chunk into a differently-sized string.
+func scanChunk(data []byte, rs *RuleSet, baseOffset int64) []model.Hit {
+	var hits []model.Hit
+
+	texts := make(map[Encoding]string, len(rs.encodingsInUse()))
+	for _, enc := range rs.encodingsInUse() {
+		texts[enc] = transform(data, enc)
 	}
 
-	return buf.String()
-}
-
-// isValidOnionChar checks if a byte belongs to the onion charset:
-// base32 ([a-z2-7]) or dot (.)
-func isValidOnionChar(b byte) bool {
-	return (b >= 'a' && b <= 'z') ||
-		(b >= 'A' && b <= 'Z') ||
-		(b >= '2' && b <= '7') ||
-		b == '.'
-}
+	for _, rule := range rs.Rules {
+		for _, enc := range rule.encodingsOrDefault() {
+			text, ok := texts[enc]
+			if !ok {
+				continue
+			}
 
-// detectUTF16LE heuristically detects UTF-16LE encoding.
-// Uses two methods:
-// 1. Check for UTF-16LE BOM (0xFF 0xFE)
-// 2. Heuristic: many null bytes in odd positions (typical of ASCII in UTF-16LE)
-func detectUTF16LE(data []byte) bool {
-	if len(data) < 2 {
-		return false
-	}
+			for _, value := range rs.evalRule(rule, text) {
+				if rule.isDenied(value) {
+					continue
+				}
 
-	// Check for BOM (Byte Order Mark)
-	if data[0] == 0xFF && data[1] == 0xFE {
-		return true
-	}
+				offset := baseOffset
+				if enc == EncodingASCII {
+					if idx := strings.Index(text, value); idx >= 0 {
+						offset = baseOffset + int64(idx)
+					}
+				}
 
-	// Heuristic: UTF-16LE has nulls in odd byte positions for ASCII text
-	nullCount := 0
-	sampleSize := min(len(data), 200)
-	for i := 1; i < sampleSize; i += 2 {
-		if data[i] == 0x00 {
-			nullCount++
+				hits = append(hits, model.Hit{
+					RuleName: rule.Name,
+					Value:    value,
+					Offset:   offset,
+					Encoding: string(enc),
+				})
+			}
 		}
 	}
 
-	// If >25% of odd bytes are null, likely UTF-16LE
-	return nullCount > sampleSize/4
+	return hits
 }
 
 // decodeUTF16LE decodes UTF-16LE byte data into a UTF-8 Go string.
@@ -332,8 +209,13 @@ func decodeUTF16LE(data []byte) string {
 ====================================================
 */
 
-// ScanForOnions scans a mounted filesystem and extracts Tor .onion
-// addresses using a worker pool architecture.
+// ScanForOnions scans fsys and extracts IOCs using a worker pool
+// architecture, evaluating every rule in rs against every file. fsys can be
+// a plain directory (os.DirFS), or a forensic image opened through
+// internal/imagefs (E01, raw/dd, VHD) — the scanner itself doesn't need to
+// know which, or whether a block device was ever mounted. Pass
+// DefaultRuleSet(), optionally merged with LoadRulesFromFile, as rs, and
+// DefaultScanOptions(), adjusted as needed, as opts.
 //
 // High-level flow:
 // 1. Walk the filesystem (main goroutine)
@@ -347,16 +229,49 @@ func decodeUTF16LE(data []byte) string {
 // - Buffered channel (100 jobs) prevents blocking filesystem walk
 // - Mutex protects shared results slice and deduplication map
 // - Atomic counter tracks files processed across workers
-func ScanForOnions(root string) ([]model.Onion, error) {
+//
+// Crash recovery: if opts.CheckpointPath is set, every finished file is
+// appended to it as a CheckpointEntry; if opts.Resume is also set, entries
+// already there are loaded first and any file whose size and mtime still
+// match its recorded entry is skipped rather than re-scanned. A single
+// unreadable path (commonly os.IsPermission, on an E01 with foreign ACLs)
+// no longer aborts the walk either: it's recorded to opts.SkippedPath and
+// the walk continues.
+func ScanForOnions(fsys fs.FS, rs *RuleSet, opts ScanOptions) ([]model.Hit, error) {
+
+	// Files already scanned and checkpointed by an earlier, interrupted run.
+	// A nil resumeFrom (Resume not set, or no checkpoint file yet) means
+	// every file is scanned, same as before checkpointing existed.
+	var resumeFrom map[string]CheckpointEntry
+	if opts.Resume && opts.CheckpointPath != "" {
+		loaded, err := LoadCheckpoint(opts.CheckpointPath)
+		if err != nil {
+			return nil, fmt.Errorf("loading checkpoint %s: %w", opts.CheckpointPath, err)
+		}
+		resumeFrom = loaded
+		fmt.Printf("[*] Resuming: %d file(s) already checkpointed\n", len(resumeFrom))
+	}
+
+	var checkpoint *Checkpoint
+	if opts.CheckpointPath != "" {
+		var err error
+		checkpoint, err = OpenCheckpoint(opts.CheckpointPath)
+		if err != nil {
+			return nil, fmt.Errorf("opening checkpoint %s: %w", opts.CheckpointPath, err)
+		}
+		defer checkpoint.Close()
+	}
+
+	skipped := &skippedLog{}
 
 	// Final results slice (shared between workers, protected by mutex)
-	results := []model.Onion{}
+	results := []model.Hit{}
 
 	// Mutex protecting concurrent access to results + seen map
 	resultsMux := sync.Mutex{}
 
-	// Global deduplication map: key format = "onion_value|path"
-	// Allows same onion in different files but deduplicates within same file
+	// Global deduplication map: key format = "rule|value|path"
+	// Allows the same value in different files but deduplicates within same file
 	seen := make(map[string]bool)
 
 	// Buffered channel used as a job queue (100 jobs buffer)
@@ -366,8 +281,10 @@ func ScanForOnions(root string) ([]model.Onion, error) {
 	// WaitGroup used to wait for all workers to finish processing
 	wg := sync.WaitGroup{}
 
-	// Build exclusion list (system directories not relevant for forensic analysis)
-	excluded := buildExcludedPaths(root)
+	// Chunk fingerprints already scanned anywhere in this run, so
+	// byte-identical chunks (pagefile runs, duplicated VSS snapshots, ...)
+	// are scanned once and skipped on every later encounter
+	fps := NewChunkFingerprints()
 
 	// Atomic counter for progress reporting (thread-safe increment)
 	var filesProcessed uint64
@@ -389,13 +306,13 @@ func ScanForOnions(root string) ([]model.Onion, error) {
 			case <-ticker.C:
 				// Safely read current progress
 				resultsMux.Lock()
-				onionCount := len(results)
+				hitCount := len(results)
 				resultsMux.Unlock()
 
 				fmt.Printf(
-					"[*] Scanning... files processed: %d | onions found: %d | elapsed: %s\n",
+					"[*] Scanning... files processed: %d | hits found: %d | elapsed: %s\n",
 					atomic.LoadUint64(&filesProcessed),
-					onionCount,
+					hitCount,
 					time.Since(start).Truncate(time.Minute),
 				)
 
@@ -409,9 +326,13 @@ func ScanForOnions(root string) ([]model.Onion, error) {
 		----------------------------------------------------
 		 Worker pool
 		----------------------------------------------------
-		NumWorkers goroutines process files concurrently
+		opts.Workers goroutines process files concurrently
 	*/
-	for i := 0; i < NumWorkers; i++ {
+	numWorkers := opts.Workers
+	if numWorkers <= 0 {
+		numWorkers = NumWorkers
+	}
+	for i := 0; i < numWorkers; i++ {
 		wg.Add(1)
 
 		go func() {
@@ -424,23 +345,42 @@ func ScanForOnions(root string) ([]model.Onion, error) {
 				current := atomic.AddInt32(&activeWorkers, 1)
 				updateMax(current)
 
-				// Scan one file for onion addresses
-				onions := scanFile(job.Path)
+				// Scan one file against every rule
+				fileHits := scanFile(fsys, job.Path, rs, fps, skipped)
 
 				// File processed
 				atomic.AddUint64(&filesProcessed, 1)
 
 				// Merge results
 				resultsMux.Lock()
-				for _, onion := range onions {
-					key := onion.Value + "|" + onion.Path
+				for _, hit := range fileHits {
+					key := hitKey(hit) + "|" + hit.Path
 					if !seen[key] {
 						seen[key] = true
-						results = append(results, onion)
+						results = append(results, hit)
 					}
 				}
 				resultsMux.Unlock()
 
+				if checkpoint != nil {
+					onionsFound := 0
+					for _, hit := range fileHits {
+						if hit.RuleName == RuleTorOnionV3 {
+							onionsFound++
+						}
+					}
+					entry := CheckpointEntry{
+						Path:        job.Path,
+						Size:        job.Info.Size(),
+						ModTime:     job.Info.ModTime(),
+						ContentHash: contentWindowHash(fsys, job.Path),
+						OnionsFound: onionsFound,
+					}
+					if err := checkpoint.Append(entry); err != nil {
+						fmt.Fprintf(os.Stderr, "[!] Failed to checkpoint %s: %v\n", job.Path, err)
+					}
+				}
+
 				// ---- Worker done (DEBUG) ----
 				atomic.AddInt32(&activeWorkers, -1)
 			}
@@ -453,58 +393,58 @@ func ScanForOnions(root string) ([]model.Onion, error) {
 		----------------------------------------------------
 		Main goroutine walks filesystem and dispatches files to workers
 	*/
-	err := filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
-		if err != nil {
-			return nil // skip unreadable paths
-		}
+	var filesDispatched uint64
 
-		// Skip excluded directories (Windows system folders)
-		if isExcludedPath(path, excluded) {
-			if d.IsDir() {
-				return filepath.SkipDir // don't recurse into excluded dirs
+	err := walk(fsys, ".", opts, func(p string, info fs.FileInfo, depth int) error {
+		// Skip excluded directories (Windows system folders, by default)
+		if opts.isExcludedPath(p) {
+			if info.IsDir() {
+				return fs.SkipDir // don't recurse into excluded dirs
 			}
 			return nil
 		}
 
-		// Detect onion addresses in filenames themselves
-		filename := filepath.Base(path)
-		matches := onionRegex.FindAllString(filename, -1)
-		if len(matches) > 0 {
+		// Run every rule against the filename itself, not just file contents
+		filename := path.Base(p)
+		nameHits := scanChunk([]byte(filename), rs, -1)
+		if len(nameHits) > 0 {
 			resultsMux.Lock()
-			for _, match := range matches {
-				value := strings.ToLower(match)
-
-				if isGenericOnion(value) {
-					continue
-				}
-
-				key := value + "|" + path
+			for _, hit := range nameHits {
+				hit.Path = p
+				key := hitKey(hit) + "|" + hit.Path
 				if !seen[key] {
 					seen[key] = true
-					results = append(results, model.Onion{
-						Value: value,
-						Path:  path,
-					})
+					results = append(results, hit)
 				}
 			}
 			resultsMux.Unlock()
 		}
 
 		// Do not send directories to workers (only files)
-		if d.IsDir() {
+		if info.IsDir() {
+			return nil
+		}
+
+		// Skip files larger than opts.MaxFileSize (0 = unlimited)
+		if opts.MaxFileSize > 0 && info.Size() > opts.MaxFileSize {
 			return nil
 		}
 
-		// Skip very large files (>500MB) to avoid excessive processing time
-		info, err := d.Info()
-		if err != nil || info.Size() > 500*1024*1024 {
+		// Resume: a file already checkpointed with a matching size and
+		// mtime was scanned by an earlier, interrupted run
+		if entry, ok := resumeFrom[p]; ok && entry.matches(info) {
 			return nil
 		}
 
 		// Send file to worker pool via job channel
-		jobs <- FileJob{Path: path, Info: info}
+		jobs <- FileJob{Path: p, Info: info}
+
+		filesDispatched++
+		if opts.MaxTotalFiles > 0 && filesDispatched >= opts.MaxTotalFiles {
+			return fs.SkipAll // reached the cap, stop walking cleanly
+		}
 		return nil
-	})
+	}, skipped.record)
 
 	// Close job channel to signal workers that no more jobs are coming
 	close(jobs)
@@ -515,19 +455,27 @@ func ScanForOnions(root string) ([]model.Onion, error) {
 	// Stop progress reporting goroutine
 	close(done)
 
+	if opts.SkippedPath != "" {
+		if writeErr := skipped.writeTo(opts.SkippedPath); writeErr != nil {
+			fmt.Fprintf(os.Stderr, "[!] Failed to write %s: %v\n", opts.SkippedPath, writeErr)
+		}
+	}
+
 	// Print final summary
 	fmt.Printf(
-		"[+] Scan finished: %d files processed | %d onions found | total time: %s\n",
+		"[+] Scan finished: %d files processed | %d hits found | total time: %s\n",
 		filesProcessed,
 		len(results),
 		time.Since(start).Truncate(time.Minute),
 	)
 
-	fmt.Printf(
-		"[DEBUG] Max concurrent workers used: %d / %d\n",
-		maxActiveWorkers,
-		NumWorkers,
-	)
+	if opts.Verbose {
+		fmt.Printf(
+			"[DEBUG] Max concurrent workers used: %d / %d\n",
+			maxActiveWorkers,
+			numWorkers,
+		)
+	}
 
 	return results, err
 }
@@ -0,0 +1,160 @@
+package scanner_test
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"onion-finder/internal/imagefs"
+	"onion-finder/internal/scanner"
+)
+
+// onionMarker is a syntactically valid Tor v3 onion address (random, not
+// one of the well-known entry points DefaultRuleSet's deny list excludes),
+// embedded in each synthetic image below.
+const onionMarker = "hbrpoigf3cbfnobm2o4rak3vrjnvgfygwwqc5hyfsxmecosfogyr5xkx.onion"
+
+// TestScanForOnionsAcrossBackends is an end-to-end check that a hit buried
+// in each imagefs backend (directory, raw/dd, fixed VHD, single-segment
+// EWF/E01) survives detection through the same path a real run takes:
+// imagefs.Open -> scanner.ScanForOnions. This is the path the singleFileFS
+// "." regression (see imagefs.TestSingleFileFSWalkable) silently broke for
+// every non-directory backend.
+func TestScanForOnionsAcrossBackends(t *testing.T) {
+	content := []byte("some forensic image bytes with " + onionMarker + " embedded in them")
+
+	tests := []struct {
+		name  string
+		build func(t *testing.T, dir string) string // returns the path to scan
+	}{
+		{"directory", func(t *testing.T, dir string) string {
+			root := filepath.Join(dir, "root")
+			if err := os.Mkdir(root, 0o755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(filepath.Join(root, "file.txt"), content, 0o644); err != nil {
+				t.Fatal(err)
+			}
+			return root
+		}},
+		{"raw", func(t *testing.T, dir string) string {
+			path := filepath.Join(dir, "image.dd")
+			if err := os.WriteFile(path, content, 0o644); err != nil {
+				t.Fatal(err)
+			}
+			return path
+		}},
+		{"vhd", func(t *testing.T, dir string) string {
+			path := filepath.Join(dir, "image.vhd")
+			if err := os.WriteFile(path, buildFixedVHD(content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			return path
+		}},
+		{"e01", func(t *testing.T, dir string) string {
+			path := filepath.Join(dir, "image.E01")
+			if err := os.WriteFile(path, buildSingleSegmentEWF(content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			return path
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := tt.build(t, t.TempDir())
+
+			fsys, closer, err := imagefs.Open(path)
+			if err != nil {
+				t.Fatalf("imagefs.Open: %v", err)
+			}
+			defer closer.Close()
+
+			opts := scanner.DefaultScanOptions()
+			opts.SkippedPath = ""
+			opts.CheckpointPath = ""
+
+			hits, err := scanner.ScanForOnions(fsys, scanner.DefaultRuleSet(), opts)
+			if err != nil {
+				t.Fatalf("ScanForOnions: %v", err)
+			}
+
+			found := false
+			for _, h := range hits {
+				if h.RuleName == scanner.RuleTorOnionV3 && h.Value == onionMarker {
+					found = true
+				}
+			}
+			if !found {
+				t.Fatalf("ScanForOnions over %s backend found %d hit(s), none matching %q", tt.name, len(hits), onionMarker)
+			}
+		})
+	}
+}
+
+// buildFixedVHD wraps content in a minimal fixed-format VHD: imagefs.DetectFormat
+// sniffs the "conectix" cookie at the start of the file, and vhdSource trims a
+// trailing 512-byte footer (also carrying the cookie, plus the fixed-disk-type
+// field vhdSource checks) off the back to recover the disk data.
+func buildFixedVHD(content []byte) []byte {
+	const vhdCookie = "conectix"
+	const vhdTypeFixed = 2
+
+	buf := append([]byte(vhdCookie), content...)
+
+	footer := make([]byte, 512)
+	copy(footer[0:8], vhdCookie)
+	binary.BigEndian.PutUint32(footer[60:64], vhdTypeFixed)
+
+	return append(buf, footer...)
+}
+
+// buildSingleSegmentEWF builds a minimal single-segment EWF/E01 file holding
+// content as one uncompressed chunk: a 13-byte header, then a linked list of
+// "volume" (sector geometry), "sectors" (the raw chunk), "table" (the chunk's
+// offset) and "done" sections, matching just enough of the layout
+// internal/imagefs/ewf.go's openEWFImage understands.
+func buildSingleSegmentEWF(content []byte) []byte {
+	const ewfSignature = "EVF\x09\x0d\x0a\xff\x00"
+
+	descriptor := func(typeName string, next, size int64) []byte {
+		d := make([]byte, 76)
+		copy(d[0:16], typeName)
+		binary.LittleEndian.PutUint64(d[16:24], uint64(next))
+		binary.LittleEndian.PutUint64(d[24:32], uint64(size))
+		return d
+	}
+
+	header := make([]byte, 13)
+	copy(header, ewfSignature)
+
+	volData := make([]byte, 28)
+	binary.LittleEndian.PutUint32(volData[4:8], 1)                      // sector count
+	binary.LittleEndian.PutUint32(volData[8:12], 1)                     // sectors per chunk
+	binary.LittleEndian.PutUint32(volData[12:16], uint32(len(content))) // bytes per sector
+
+	tableData := make([]byte, 28) // 24-byte header (entry count + padding) + 1 entry
+	binary.LittleEndian.PutUint32(tableData[0:4], 1)
+	binary.LittleEndian.PutUint32(tableData[24:28], 0) // chunk 0, relative offset 0, uncompressed
+
+	var buf []byte
+	buf = append(buf, header...)
+
+	volOff := int64(len(buf))
+	sectorsOff := volOff + 76 + int64(len(volData))
+	buf = append(buf, descriptor("volume", sectorsOff, int64(len(volData)))...)
+	buf = append(buf, volData...)
+
+	tableOff := sectorsOff + 76 + int64(len(content))
+	buf = append(buf, descriptor("sectors", tableOff, int64(len(content)))...)
+	buf = append(buf, content...)
+
+	doneOff := tableOff + 76 + int64(len(tableData))
+	buf = append(buf, descriptor("table", doneOff, int64(len(tableData)))...)
+	buf = append(buf, tableData...)
+
+	buf = append(buf, descriptor("done", 0, 0)...)
+
+	return buf
+}
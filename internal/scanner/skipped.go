@@ -0,0 +1,51 @@
+package scanner
+
+import (
+	"fmt"
+	"os"
+	"sync"
+)
+
+// skippedLog collects the paths ScanForOnions's walk and file opens had to
+// give up on, tagging each with whether it was specifically a permission
+// error (the common case on a forensic image with foreign ACLs) or
+// something else, then writes them all to ScanOptions.SkippedPath in one
+// pass at the end of the scan.
+type skippedLog struct {
+	mu      sync.Mutex
+	entries []string
+}
+
+// record appends one skipped path, distinguishing a permission error from
+// any other Stat/ReadDir/Open failure. Safe for concurrent use.
+func (s *skippedLog) record(path string, err error) {
+	reason := "error"
+	if os.IsPermission(err) {
+		reason = "permission denied"
+	}
+
+	s.mu.Lock()
+	s.entries = append(s.entries, fmt.Sprintf("%s: %s (%v)", path, reason, err))
+	s.mu.Unlock()
+}
+
+// writeTo writes every recorded entry to path, one per line. An empty log
+// still produces an (empty) file, so a previous run's stale skip list
+// never lingers and is mistaken for this run's.
+func (s *skippedLog) writeTo(path string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	for _, entry := range s.entries {
+		if _, err := fmt.Fprintln(f, entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
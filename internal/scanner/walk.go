@@ -0,0 +1,211 @@
+package scanner
+
+import (
+	"io/fs"
+	"path"
+	"strings"
+	"sync"
+)
+
+// ScanOptions bounds how aggressively ScanForOnions walks a filesystem.
+// Forensic mounts can contain symlink cycles, deep directory nesting, or
+// reparse points/junctions that loop back on an ancestor — without limits,
+// an unbounded walk can recurse forever or exhaust the stack, the same
+// class of bug Go itself has patched in path/filepath.Glob and io/fs.Glob.
+type ScanOptions struct {
+	// MaxDepth caps how many directory levels are descended below the scan
+	// root. 0 means unlimited.
+	MaxDepth int
+
+	// FollowSymlinks allows the walker to descend into directories reached
+	// through a symlink. Off by default: a symlink looping back to an
+	// ancestor would otherwise be followed every time it's seen, relying on
+	// the visited set below to eventually cut it off rather than never
+	// following it at all.
+	FollowSymlinks bool
+
+	// SkipReparsePoints skips symlinks and Windows reparse points/junctions
+	// entirely, even when FollowSymlinks is set. Symlinks are only walked
+	// when SkipReparsePoints is off and FollowSymlinks is on.
+	SkipReparsePoints bool
+
+	// MaxTotalFiles stops the walk after this many files have been
+	// dispatched for scanning. 0 means unlimited.
+	MaxTotalFiles uint64
+
+	// MaxFileSize skips files larger than this many bytes. 0 means
+	// unlimited.
+	MaxFileSize int64
+
+	// Workers is how many goroutines ScanForOnions's worker pool uses to
+	// scan dispatched files concurrently. 0 means NumWorkers.
+	Workers int
+
+	// ExcludedTopLevelDirs lists top-level directory names, matched
+	// case-insensitively against the first path component, that are never
+	// descended into.
+	ExcludedTopLevelDirs map[string]bool
+
+	// SkippedPath, if set, is where ScanForOnions logs every path a
+	// permission error or other Stat/ReadDir/Open failure forced it to
+	// skip, one per line, instead of silently dropping it the way a plain
+	// "continue" would.
+	SkippedPath string
+
+	// CheckpointPath, if set, is where ScanForOnions appends a
+	// CheckpointEntry as each file finishes scanning, so a crash partway
+	// through a multi-hour scan doesn't force a full restart.
+	CheckpointPath string
+
+	// Resume, when true, loads CheckpointPath (if it exists) before
+	// walking and skips any file whose recorded entry still matches its
+	// current size and mtime, rather than re-scanning it.
+	Resume bool
+
+	// Verbose enables ScanForOnions's DEBUG-level output (currently just
+	// the worker-concurrency line), in addition to the summary it always
+	// prints. Off by default so a quiet run stays quiet.
+	Verbose bool
+}
+
+// DefaultScanOptions returns the limits ScanForOnions used to apply
+// unconditionally before ScanOptions existed: a 500 MB per-file cap and the
+// Windows system-directory exclusion list, now adjustable instead of
+// hard-coded, plus the depth/cycle protection that was entirely missing.
+func DefaultScanOptions() ScanOptions {
+	return ScanOptions{
+		MaxDepth:          256,
+		FollowSymlinks:    false,
+		SkipReparsePoints: true,
+		MaxTotalFiles:     0,
+		MaxFileSize:       500 * 1024 * 1024,
+		Workers:           NumWorkers,
+		ExcludedTopLevelDirs: map[string]bool{
+			"windows":             true,
+			"program files":       true,
+			"program files (x86)": true,
+			"perflogs":            true,
+		},
+		SkippedPath:    "skipped.txt",
+		CheckpointPath: "scan.checkpoint.jsonl",
+	}
+}
+
+// isExcludedPath reports whether p falls under one of opts'
+// ExcludedTopLevelDirs.
+func (opts ScanOptions) isExcludedPath(p string) bool {
+	first, _, _ := strings.Cut(path.Clean(p), "/")
+	return opts.ExcludedTopLevelDirs[strings.ToLower(first)]
+}
+
+// walkItem is one pending entry on the iterative walker's explicit stack.
+type walkItem struct {
+	path  string
+	depth int
+}
+
+// visitFunc is called for every entry walk reaches, in a depth-first but
+// iterative order. Returning fs.SkipDir skips a directory's children;
+// returning fs.SkipAll stops the walk entirely. Neither is treated as a
+// failure by walk.
+type visitFunc func(p string, info fs.FileInfo, depth int) error
+
+// SkipLogger receives every path walk had to give up on (a Stat or ReadDir
+// that failed), along with the error, so the caller can tell a permission
+// error on one directory apart from every other path that was silently
+// unreadable, rather than losing that distinction in a bare "continue". May
+// be nil, in which case walk behaves exactly as it did before skip logging:
+// unreadable paths are skipped with no record of why.
+type SkipLogger func(p string, err error)
+
+// walk iteratively traverses fsys starting at root using an explicit stack
+// instead of recursion, so a symlink cycle is bounded by stack size rather
+// than the call stack. It enforces opts' depth and reparse-point/symlink
+// rules, and cycle-detects via each entry's fileIdentity (device+inode on
+// platforms that expose one) so two paths naming the same underlying file
+// or directory are only visited once. onSkip, if non-nil, is called for
+// every path a Stat or ReadDir failure forces walk to skip — most commonly
+// os.IsPermission errors on an E01 image with mismatched ACLs, but walk
+// doesn't care which; it reports whatever the filesystem returned and
+// keeps going.
+func walk(fsys fs.FS, root string, opts ScanOptions, visit visitFunc, onSkip SkipLogger) error {
+	var visited sync.Map // fileIdentity string -> struct{}{}
+
+	stack := []walkItem{{path: root, depth: 0}}
+
+	// pushEntries queues dir's children onto stack, in reverse so they pop
+	// back out in the same (sorted) order fs.ReadDir returned them in,
+	// skipping symlinks per opts.
+	pushEntries := func(dir walkItem, entries []fs.DirEntry) {
+		for i := len(entries) - 1; i >= 0; i-- {
+			entry := entries[i]
+
+			if entry.Type()&fs.ModeSymlink != 0 && (opts.SkipReparsePoints || !opts.FollowSymlinks) {
+				continue
+			}
+
+			stack = append(stack, walkItem{path: path.Join(dir.path, entry.Name()), depth: dir.depth + 1})
+		}
+	}
+
+	for len(stack) > 0 {
+		item := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		info, err := fs.Stat(fsys, item.path)
+		if err != nil {
+			// A backend that can list a directory's entries but doesn't
+			// implement Stat for the directory itself (e.g. one that only
+			// keeps per-file metadata) would otherwise lose its entire
+			// tree here, since the root is always stat'd before anything
+			// is read. Fall back to treating it as a directory via
+			// ReadDir before giving up on it.
+			if item.path == root {
+				if entries, rdErr := fs.ReadDir(fsys, item.path); rdErr == nil {
+					pushEntries(item, entries)
+					continue
+				}
+			}
+			if onSkip != nil {
+				onSkip(item.path, err)
+			}
+			continue // unreadable path, skip it
+		}
+
+		if id, ok := fileIdentity(item.path, info); ok {
+			if _, dup := visited.LoadOrStore(id, struct{}{}); dup {
+				continue // already visited this underlying file or directory
+			}
+		}
+
+		if err := visit(item.path, info, item.depth); err != nil {
+			if err == fs.SkipDir {
+				continue
+			}
+			if err == fs.SkipAll {
+				return nil
+			}
+			return err
+		}
+
+		if !info.IsDir() {
+			continue
+		}
+
+		if opts.MaxDepth > 0 && item.depth >= opts.MaxDepth {
+			continue // don't descend further
+		}
+
+		entries, err := fs.ReadDir(fsys, item.path)
+		if err != nil {
+			if onSkip != nil {
+				onSkip(item.path, err)
+			}
+			continue
+		}
+
+		pushEntries(item, entries)
+	}
+
+	return nil
+}
@@ -0,0 +1,364 @@
+package tor
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/proxy"
+)
+
+// CheckMode selects what CheckOnionsList actually does to each onion.
+type CheckMode string
+
+const (
+	// ModeTCP dials <onion>:<port> and only checks whether the connection
+	// succeeds — the original liveness probe.
+	ModeTCP CheckMode = "tcp"
+	// ModeHTTP issues a GET / over plain HTTP and records the response.
+	ModeHTTP CheckMode = "http"
+	// ModeHTTPS issues a GET / over HTTPS (TLS through the SOCKS tunnel)
+	// and records the response.
+	ModeHTTPS CheckMode = "https"
+)
+
+// CheckOptions configures CheckOnionsList.
+type CheckOptions struct {
+	// ProxyAddress is the Tor SOCKS5 proxy every dialer connects through.
+	ProxyAddress string
+
+	// Timeout bounds every per-onion operation: the SOCKS connect, the
+	// HTTP(S) round trip, and the TLS handshake harvestTLS performs. A
+	// forensic run can easily produce thousands of onions, and a handful
+	// of unreachable ones hanging at their default OS timeout would stall
+	// the whole batch.
+	Timeout time.Duration
+
+	// Ports are the TCP ports to connect to on every onion. Each onion is
+	// checked once per port, so a 3-onion list with 2 ports produces 6
+	// results, keyed by (onion, port) in Result and WriteResultsTable.
+	Ports []int
+
+	// Mode selects TCP liveness, HTTP, or HTTPS probing.
+	Mode CheckMode
+
+	// Concurrency is how many onions are checked at once. Each worker gets
+	// its own SOCKS5 dialer with a distinct username/password pair — Tor's
+	// IsolateSOCKSAuth treats each distinct pair as a separate circuit, so
+	// this is real parallelism, not just concurrent requests funneled
+	// through one circuit.
+	Concurrency int
+
+	// BatchSize, if > 0, splits the onion list into batches of this size.
+	// ControlPort is signaled for a fresh set of circuits between batches.
+	// 0 means no batching: every onion is checked in one pass.
+	BatchSize int
+
+	// ControlPort, if > 0, is used to send SIGNAL NEWNYM between batches
+	// so each batch gets fresh Tor circuits. Requires a Tor control port
+	// with no password (or cookie auth disabled) listening on localhost.
+	ControlPort int
+
+	// HarvestTLS, in ModeTCP, performs a TLS handshake over a second
+	// connection to every reachable onion and records the certificate
+	// chain on Result.TLS. It has no effect in the other modes: ModeHTTPS
+	// always records the chain from the handshake already done for the
+	// HTTP probe, and ModeHTTP is an explicitly plaintext probe.
+	HarvestTLS bool
+}
+
+// DefaultCheckOptions returns the settings CheckOnions used unconditionally
+// before CheckOptions existed: a TCP liveness probe per onion on port 443,
+// now with real concurrency instead of a sequential loop, and with TLS
+// harvesting on, which adds a second connection to each reachable onion.
+func DefaultCheckOptions() CheckOptions {
+	return CheckOptions{
+		ProxyAddress: DefaultSOCKSAddr,
+		Timeout:      20 * time.Second,
+		Ports:        []int{443},
+		Mode:         ModeTCP,
+		Concurrency:  8,
+		HarvestTLS:   true,
+	}
+}
+
+func (o CheckOptions) withDefaults() CheckOptions {
+	if o.ProxyAddress == "" {
+		o.ProxyAddress = DefaultSOCKSAddr
+	}
+	if o.Timeout <= 0 {
+		o.Timeout = 20 * time.Second
+	}
+	if o.Concurrency <= 0 {
+		o.Concurrency = 8
+	}
+	if o.Mode == "" {
+		o.Mode = ModeTCP
+	}
+	if len(o.Ports) == 0 {
+		o.Ports = []int{443}
+	}
+	return o
+}
+
+// onionJob pairs an (onion, port) pair with the result slot it must be
+// written to, so workers can fill in results out of order without a
+// results mutex.
+type onionJob struct {
+	index int
+	onion Onion
+	port  int
+}
+
+// CheckOnionsList checks every onion in the list, opts.Concurrency at a
+// time, isolating each worker onto its own Tor circuit. If opts.BatchSize
+// is set, the list is split into batches and, when opts.ControlPort is
+// also set, a SIGNAL NEWNYM is sent between batches for a fresh set of
+// circuits.
+func CheckOnionsList(onions []Onion, opts CheckOptions) ([]Result, error) {
+	opts = opts.withDefaults()
+
+	batchSize := opts.BatchSize
+	if batchSize <= 0 || batchSize > len(onions) {
+		batchSize = len(onions)
+	}
+
+	results := make([]Result, 0, len(onions))
+	for start := 0; start < len(onions); start += batchSize {
+		end := start + batchSize
+		if end > len(onions) {
+			end = len(onions)
+		}
+
+		results = append(results, checkBatch(onions[start:end], opts)...)
+
+		if opts.ControlPort > 0 && end < len(onions) {
+			if err := signalNewnym(opts.ControlPort); err != nil {
+				fmt.Println("[!] Failed to request new Tor circuits:", err)
+			}
+		}
+	}
+
+	return results, nil
+}
+
+// checkBatch runs opts.Concurrency workers over a single batch of onions,
+// each checked once per opts.Ports, each worker dialing through its own
+// isolated SOCKS5 circuit.
+func checkBatch(onions []Onion, opts CheckOptions) []Result {
+	total := len(onions) * len(opts.Ports)
+	results := make([]Result, total)
+	jobs := make(chan onionJob)
+
+	var wg sync.WaitGroup
+	for worker := 0; worker < opts.Concurrency; worker++ {
+		wg.Add(1)
+		go func(workerID int) {
+			defer wg.Done()
+
+			dialer, err := newIsolatedDialer(workerID, opts.ProxyAddress, opts.Timeout)
+			if err != nil {
+				for job := range jobs {
+					results[job.index] = Result{Onion: job.onion, Port: job.port, Status: OnionUnknown, Error: err.Error()}
+				}
+				return
+			}
+
+			for job := range jobs {
+				results[job.index] = checkOnion(dialer, job.onion, job.port, opts)
+			}
+		}(worker)
+	}
+
+	index := 0
+	for _, onion := range onions {
+		for _, port := range opts.Ports {
+			jobs <- onionJob{index: index, onion: onion, port: port}
+			index++
+		}
+	}
+	close(jobs)
+
+	wg.Wait()
+	return results
+}
+
+// newIsolatedDialer returns a SOCKS5 dialer authenticated with credentials
+// unique to workerID, so Tor's IsolateSOCKSAuth routes it onto its own
+// circuit instead of sharing one with every other worker.
+func newIsolatedDialer(workerID int, proxyAddr string, timeout time.Duration) (proxy.Dialer, error) {
+	creds := fmt.Sprintf("onion-finder-worker-%d", workerID)
+	auth := &proxy.Auth{User: creds, Password: creds}
+
+	return proxy.SOCKS5("tcp", proxyAddr, auth, &net.Dialer{Timeout: timeout})
+}
+
+// checkOnion probes a single (onion, port) pair through dialer according to
+// opts.Mode, then, per opts.HarvestTLS, fills in its certificate chain.
+func checkOnion(dialer proxy.Dialer, onion Onion, port int, opts CheckOptions) Result {
+	var result Result
+	switch opts.Mode {
+	case ModeHTTP:
+		result = checkOnionHTTP(dialer, onion, port, false, opts.Timeout)
+	case ModeHTTPS:
+		result = checkOnionHTTP(dialer, onion, port, true, opts.Timeout)
+	default:
+		result = checkOnionTCP(dialer, onion, port)
+	}
+	result.Port = port
+
+	// ModeHTTPS already performed a handshake above and populated TLS from
+	// it; ModeTCP needs a second connection to harvest the chain; ModeHTTP
+	// is an explicitly plaintext probe, so there's nothing to harvest.
+	if opts.HarvestTLS && opts.Mode == ModeTCP && result.Status == OnionUp {
+		if info, err := harvestTLS(dialer, onion, port, opts.Timeout); err == nil {
+			result.TLS = info
+		}
+	}
+
+	return result
+}
+
+func checkOnionTCP(dialer proxy.Dialer, onion Onion, port int) Result {
+	address := fmt.Sprintf("%s:%d", onion.Value, port)
+
+	conn, err := dialer.Dial("tcp", address)
+	if err != nil {
+		return Result{Onion: onion, Status: dialFailureStatus(err), Error: err.Error()}
+	}
+	_ = conn.Close()
+
+	return Result{Onion: onion, Status: OnionUp}
+}
+
+// dialFailureStatus classifies a failed dial: a SOCKS5 CONNECT that reached
+// the onion and got turned away (connection refused) or couldn't reach it
+// at all (no route to host, network unreachable) means the service is
+// actually down; anything else (proxy errors, timeouts, a descriptor Tor
+// couldn't even resolve) is left as OnionUnknown rather than guessed at.
+func dialFailureStatus(err error) OnionStatus {
+	msg := strings.ToLower(err.Error())
+	switch {
+	case strings.Contains(msg, "connection refused"),
+		strings.Contains(msg, "no route to host"),
+		strings.Contains(msg, "network is unreachable"):
+		return OnionDown
+	default:
+		return OnionUnknown
+	}
+}
+
+// titleRe extracts the contents of an HTML <title> tag; it's deliberately
+// loose (no full HTML parser) since we only need a best-effort page title
+// for recon, not spec-correct parsing.
+var titleRe = regexp.MustCompile(`(?is)<title[^>]*>(.*?)</title>`)
+
+// maxHTTPBodyBytes caps how much of a response body checkOnionHTTP reads,
+// so a hostile or oversized page can't stall a worker indefinitely.
+const maxHTTPBodyBytes = 64 * 1024
+
+// checkOnionHTTP issues a GET / to onion over HTTP or HTTPS through dialer
+// and records status code, Server header, page title, and body size on the
+// result.
+func checkOnionHTTP(dialer proxy.Dialer, onion Onion, port int, useTLS bool, timeout time.Duration) Result {
+	scheme := "http"
+	if useTLS {
+		scheme = "https"
+	}
+
+	client := &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			DialContext: func(_ context.Context, network, addr string) (net.Conn, error) {
+				return dialer.Dial(network, addr)
+			},
+			// Onion services routinely present self-signed certs, or certs
+			// whose name has nothing to do with the onion address; we only
+			// want to harvest whatever chain is offered, not trust it.
+			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+		},
+	}
+
+	url := fmt.Sprintf("%s://%s:%d/", scheme, onion.Value, port)
+
+	resp, err := client.Get(url)
+	if err != nil {
+		return Result{Onion: onion, Status: dialFailureStatus(err), Error: err.Error()}
+	}
+	defer resp.Body.Close()
+
+	var tlsInfo *TLSInfo
+	if resp.TLS != nil {
+		tlsInfo = tlsInfoFromCerts(resp.TLS.PeerCertificates)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxHTTPBodyBytes))
+	if err != nil {
+		return Result{Onion: onion, Status: OnionUp, HTTPStatus: resp.StatusCode, TLS: tlsInfo, Error: err.Error()}
+	}
+
+	title := ""
+	if m := titleRe.FindSubmatch(body); m != nil {
+		title = string(m[1])
+	}
+
+	return Result{
+		Onion:      onion,
+		Status:     OnionUp,
+		HTTPStatus: resp.StatusCode,
+		Server:     resp.Header.Get("Server"),
+		Title:      title,
+		Size:       int64(len(body)),
+		TLS:        tlsInfo,
+	}
+}
+
+// signalNewnym asks Tor, via its control port, for a fresh set of circuits.
+// It assumes an unauthenticated (or cookie-auth-disabled) control port, as
+// Tor Browser exposes by default for local tooling; anything requiring
+// password/cookie authentication will fail here.
+func signalNewnym(controlPort int) error {
+	conn, err := net.DialTimeout("tcp", fmt.Sprintf("127.0.0.1:%d", controlPort), 5*time.Second)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	_ = conn.SetDeadline(time.Now().Add(5 * time.Second))
+	r := bufio.NewReader(conn)
+
+	if err := controlCommand(conn, r, "AUTHENTICATE\r\n"); err != nil {
+		return fmt.Errorf("control port AUTHENTICATE failed: %w", err)
+	}
+	if err := controlCommand(conn, r, "SIGNAL NEWNYM\r\n"); err != nil {
+		return fmt.Errorf("control port SIGNAL NEWNYM failed: %w", err)
+	}
+
+	return nil
+}
+
+// controlCommand sends cmd over the Tor control protocol and requires a
+// "250" success reply.
+func controlCommand(conn net.Conn, r *bufio.Reader, cmd string) error {
+	if _, err := io.WriteString(conn, cmd); err != nil {
+		return err
+	}
+
+	reply, err := r.ReadString('\n')
+	if err != nil {
+		return err
+	}
+	if len(reply) < 3 || reply[:3] != "250" {
+		return fmt.Errorf("unexpected reply: %q", reply)
+	}
+
+	return nil
+}
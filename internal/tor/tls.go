@@ -0,0 +1,118 @@
+package tor
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"time"
+
+	"golang.org/x/net/proxy"
+)
+
+// TLSCert is the subset of an x509 certificate worth recording for
+// correlating a hidden service with clearnet infrastructure: SANs and the
+// fingerprint are the strongest pivots, but the rest is cheap to keep too.
+type TLSCert struct {
+	Subject            string
+	Issuer             string
+	SANs               []string
+	SerialNumber       string
+	NotBefore          time.Time
+	NotAfter           time.Time
+	SHA256Fingerprint  string
+	SignatureAlgorithm string
+	SelfSigned         bool
+}
+
+// TLSInfo is the certificate chain a TLS handshake against an onion
+// presented, leaf first.
+type TLSInfo struct {
+	Chain []TLSCert
+}
+
+// harvestTLS dials onion:port through dialer and performs a TLS handshake,
+// skipping certificate verification (onion services routinely present
+// self-signed certs, or certs whose name has nothing to do with the onion
+// address) purely to harvest the chain for analysis, not to trust it.
+func harvestTLS(dialer proxy.Dialer, onion Onion, port int, timeout time.Duration) (*TLSInfo, error) {
+	address := fmt.Sprintf("%s:%d", onion.Value, port)
+
+	rawConn, err := dialer.Dial("tcp", address)
+	if err != nil {
+		return nil, err
+	}
+	defer rawConn.Close()
+
+	conn := tls.Client(rawConn, &tls.Config{InsecureSkipVerify: true, ServerName: onion.Value})
+	_ = conn.SetDeadline(time.Now().Add(timeout))
+	defer conn.Close()
+
+	if err := conn.Handshake(); err != nil {
+		return nil, err
+	}
+
+	return tlsInfoFromCerts(conn.ConnectionState().PeerCertificates), nil
+}
+
+// tlsInfoFromCerts converts a raw certificate chain (leaf first, as
+// presented during the handshake) into a TLSInfo.
+func tlsInfoFromCerts(certs []*x509.Certificate) *TLSInfo {
+	info := &TLSInfo{Chain: make([]TLSCert, 0, len(certs))}
+
+	for _, cert := range certs {
+		fingerprint := sha256.Sum256(cert.Raw)
+
+		info.Chain = append(info.Chain, TLSCert{
+			Subject:            cert.Subject.String(),
+			Issuer:             cert.Issuer.String(),
+			SANs:               cert.DNSNames,
+			SerialNumber:       cert.SerialNumber.String(),
+			NotBefore:          cert.NotBefore,
+			NotAfter:           cert.NotAfter,
+			SHA256Fingerprint:  hex.EncodeToString(fingerprint[:]),
+			SignatureAlgorithm: cert.SignatureAlgorithm.String(),
+			SelfSigned:         cert.Issuer.String() == cert.Subject.String(),
+		})
+	}
+
+	return info
+}
+
+// WriteTLSReport writes the certificate chains harvested for every
+// reachable onion to path, one block per onion. Results with no TLSInfo
+// (harvesting was off, the handshake failed, or the onion was down) are
+// left out.
+func WriteTLSReport(path string, results []Result) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	for _, r := range results {
+		if r.TLS == nil {
+			continue
+		}
+
+		fmt.Fprintf(file, "%s | %s\n", r.Onion.Value, r.Onion.Path)
+
+		for i, cert := range r.TLS.Chain {
+			fmt.Fprintf(file, "  [%d] Subject:    %s\n", i, cert.Subject)
+			fmt.Fprintf(file, "      Issuer:     %s\n", cert.Issuer)
+			fmt.Fprintf(file, "      SANs:       %v\n", cert.SANs)
+			fmt.Fprintf(file, "      Serial:     %s\n", cert.SerialNumber)
+			fmt.Fprintf(file, "      NotBefore:  %s\n", cert.NotBefore.UTC().Format(time.RFC3339))
+			fmt.Fprintf(file, "      NotAfter:   %s\n", cert.NotAfter.UTC().Format(time.RFC3339))
+			fmt.Fprintf(file, "      SHA256:     %s\n", cert.SHA256Fingerprint)
+			fmt.Fprintf(file, "      SigAlg:     %s\n", cert.SignatureAlgorithm)
+			fmt.Fprintf(file, "      SelfSigned: %t\n", cert.SelfSigned)
+		}
+
+		fmt.Fprintln(file)
+	}
+
+	return nil
+}
@@ -4,12 +4,12 @@ import (
 	"bufio"
 	"errors"
 	"fmt"
+	"io"
 	"net"
 	"os"
+	"strconv"
 	"strings"
 	"time"
-
-	"golang.org/x/net/proxy"
 )
 
 type Onion struct {
@@ -25,14 +25,30 @@ const (
 	OnionUnknown OnionStatus = "UNKNOWN"
 )
 
+// Result is the outcome of checking a single onion. HTTPStatus, Server,
+// Title, and Size are only populated in ModeHTTP/ModeHTTPS (see CheckMode);
+// they stay zero for a plain TCP liveness check.
 type Result struct {
 	Onion  Onion
+	Port   int
 	Status OnionStatus
 	Error  string
+
+	HTTPStatus int
+	Server     string
+	Title      string
+	Size       int64
+
+	// TLS is the certificate chain harvested from a TLS handshake against
+	// the onion (ModeHTTPS, or ModeTCP with CheckOptions.HarvestTLS), or
+	// nil if harvesting was off in ModeTCP, failed, or doesn't apply (the
+	// onion was down, or ModeHTTP was used on a plaintext port).
+	TLS *TLSInfo
 }
 
-// Default Tor Browser SOCKS proxy
-const torSocksAddr = "127.0.0.1:9150"
+// DefaultSOCKSAddr is the Tor Browser SOCKS proxy address used when
+// CheckOptions.ProxyAddress isn't set.
+const DefaultSOCKSAddr = "127.0.0.1:9150"
 
 func statusText(status OnionStatus) string {
 	return string(status)
@@ -42,10 +58,11 @@ func line(w int) string {
 	return strings.Repeat("-", w)
 }
 
-func computeColumnWidths(results []Result) (linkW, pathW, statusW int) {
+func computeColumnWidths(results []Result) (linkW, pathW, statusW, portW int) {
 	linkW = len("Link")
 	pathW = len("Path")
 	statusW = len("Status")
+	portW = len("Port")
 
 	for _, r := range results {
 		if len(r.Onion.Value) > linkW {
@@ -57,13 +74,17 @@ func computeColumnWidths(results []Result) (linkW, pathW, statusW int) {
 		if len(statusText(r.Status)) > statusW {
 			statusW = len(statusText(r.Status))
 		}
+		if n := len(strconv.Itoa(r.Port)); n > portW {
+			portW = n
+		}
 	}
 	return
 }
 
-// CheckTorAvailable verifies that Tor SOCKS proxy is reachable
-func CheckTorAvailable() error {
-	conn, err := net.DialTimeout("tcp", torSocksAddr, 3*time.Second)
+// CheckTorAvailable verifies that the Tor SOCKS proxy at proxyAddr is
+// reachable.
+func CheckTorAvailable(proxyAddr string) error {
+	conn, err := net.DialTimeout("tcp", proxyAddr, 3*time.Second)
 	if err != nil {
 		return errors.New("Tor SOCKS proxy not reachable (is Tor Browser running?)")
 	}
@@ -98,34 +119,34 @@ func ParseOnionFile(path string) ([]Onion, error) {
 	return onions, scanner.Err()
 }
 
-// CheckOnionTCP checks onion availability via TCP using Tor
-func CheckOnionTCP(onion Onion, port int) Result {
-	netDialer := &net.Dialer{
-		Timeout: 15 * time.Second,
-	}
-
-	dialer, err := proxy.SOCKS5("tcp", torSocksAddr, nil, netDialer)
-	if err != nil {
-		return Result{Onion: onion, Status: OnionUnknown, Error: err.Error()}
-	}
-
-	address := fmt.Sprintf("%s:%d", onion.Value, port)
+// ParseOnionList reads a plain newline-delimited list of onion addresses
+// from r — one per line, no "| path" suffix — unlike ParseOnionFile, which
+// expects the pipe-delimited format onion.txt is written in. This is the
+// format for a list an analyst hand-maintains, re-checks from an earlier
+// run's output, or pulls in from another tool. Blank lines and lines
+// starting with "#" are skipped.
+func ParseOnionList(r io.Reader) ([]Onion, error) {
+	var onions []Onion
+	scanner := bufio.NewScanner(r)
 
-	conn, err := dialer.Dial("tcp", address)
-	if err != nil {
-		if strings.Contains(err.Error(), "connection refused") {
-			return Result{Onion: onion, Status: OnionDown}
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
 		}
-		return Result{Onion: onion, Status: OnionUnknown, Error: err.Error()}
+		onions = append(onions, Onion{Value: line})
 	}
 
-	_ = conn.Close()
-	return Result{Onion: onion, Status: OnionUp}
+	return onions, scanner.Err()
 }
 
-// CheckOnions checks all onions from a file
-func CheckOnions(onionFile string, port int) ([]Result, error) {
-	if err := CheckTorAvailable(); err != nil {
+// CheckOnions checks all onions from a file. See CheckOptions for the
+// concurrency, check-mode, and circuit-isolation knobs; the worker pool
+// itself lives in check.go.
+func CheckOnions(onionFile string, opts CheckOptions) ([]Result, error) {
+	opts = opts.withDefaults()
+
+	if err := CheckTorAvailable(opts.ProxyAddress); err != nil {
 		return nil, err
 	}
 
@@ -134,14 +155,13 @@ func CheckOnions(onionFile string, port int) ([]Result, error) {
 		return nil, err
 	}
 
-	results := make([]Result, 0, len(onions))
-	for _, onion := range onions {
-		results = append(results, CheckOnionTCP(onion, port))
-	}
-
-	return results, nil
+	return CheckOnionsList(onions, opts)
 }
 
+// WriteResultsTable renders results as a box-drawn ASCII table, one row per
+// (onion, port) pair — CheckOnionsList produces one Result per port probed
+// on each onion, so the Port column is what tells two rows for the same
+// onion apart.
 func WriteResultsTable(path string, results []Result) error {
 	file, err := os.Create(path)
 	if err != nil {
@@ -151,23 +171,24 @@ func WriteResultsTable(path string, results []Result) error {
 
 	w := bufio.NewWriter(file)
 
-	linkW, pathW, statusW := computeColumnWidths(results)
+	linkW, pathW, statusW, portW := computeColumnWidths(results)
 
-	fmt.Fprintf(w, "+-%s-+-%s-+-%s-+\n", line(linkW), line(pathW), line(statusW))
-	fmt.Fprintf(w, "| %-*s | %-*s | %-*s |\n", linkW, "Link", pathW, "Path", statusW, "Status")
-	fmt.Fprintf(w, "+-%s-+-%s-+-%s-+\n", line(linkW), line(pathW), line(statusW))
+	fmt.Fprintf(w, "+-%s-+-%s-+-%s-+-%s-+\n", line(linkW), line(pathW), line(statusW), line(portW))
+	fmt.Fprintf(w, "| %-*s | %-*s | %-*s | %-*s |\n", linkW, "Link", pathW, "Path", statusW, "Status", portW, "Port")
+	fmt.Fprintf(w, "+-%s-+-%s-+-%s-+-%s-+\n", line(linkW), line(pathW), line(statusW), line(portW))
 
 	for _, r := range results {
 		fmt.Fprintf(
 			w,
-			"| %-*s | %-*s | %-*s |\n",
+			"| %-*s | %-*s | %-*s | %-*d |\n",
 			linkW, r.Onion.Value,
 			pathW, r.Onion.Path,
 			statusW, statusText(r.Status),
+			portW, r.Port,
 		)
 	}
 
-	fmt.Fprintf(w, "+-%s-+-%s-+-%s-+\n", line(linkW), line(pathW), line(statusW))
+	fmt.Fprintf(w, "+-%s-+-%s-+-%s-+-%s-+\n", line(linkW), line(pathW), line(statusW), line(portW))
 
 	return w.Flush()
 }
@@ -3,50 +3,110 @@ package main
 import (
 	"flag"
 	"fmt"
+	"io"
+	"io/fs"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
 	"onion-finder/internal"
+	"onion-finder/internal/config"
+	"onion-finder/internal/imagefs"
+	"onion-finder/internal/report"
 	"onion-finder/internal/scanner"
 	"onion-finder/internal/tor"
 )
 
 func main() {
+	cfg := config.Default()
+
 	// --- Flags ---
-	keepMounted := flag.Bool("keep-mounted", false, "Keep E01 image mounted after scan")
-	dismount := flag.Bool("dismount", false, "Dismount last mounted E01 image")
+	listMounts := flag.Bool("list-mounts", false, "List active mounts tracked in the mount state file and exit")
+	dismountFlag := flag.String("dismount", "", `Dismount an active mount by ID (see -list-mounts); "last" dismounts the most recently mounted entry`)
+	mountFlag := flag.Bool("mount", false, "Mount the image through an external FUSE helper (ewfmount/xmount/affuse) instead of reading it in-process; required for AFF and qcow2, which imagefs can't parse natively")
+	formatFlag := flag.String("format", "table", "Report format: table|json|csv|sarif (table keeps writing onion.txt/results.txt as before)")
+	outputFlag := flag.String("output", "", "Report output path for -format=json|csv|sarif (default: report.<ext>)")
+	flag.StringVar(&cfg.TorProxyAddress, "proxy", cfg.TorProxyAddress, "Tor SOCKS5 proxy address")
+	flag.DurationVar(&cfg.Timeout, "timeout", cfg.Timeout, "Per-onion timeout for the Tor check (connect, HTTP round trip, TLS handshake)")
+	flag.IntVar(&cfg.Workers, "workers", cfg.Workers, "Concurrent workers used for both the filesystem scan and the Tor check")
+	flag.DurationVar(&cfg.BootstrapWait, "bootstrap-wait", cfg.BootstrapWait, "Wait after the scan/extract phases before checking Tor availability, to give a freshly-started Tor daemon time to bootstrap (0 to skip)")
+	flag.IntVar(&cfg.ScanDepth, "scan-depth", cfg.ScanDepth, "Maximum directory depth the filesystem scan descends below the image root")
+	flag.BoolVar(&cfg.Verbose, "verbose", cfg.Verbose, "Print progress detail in addition to results")
+	flag.BoolVar(&cfg.Resume, "resume", cfg.Resume, "Skip files already recorded in scan.checkpoint.jsonl from a previous, interrupted run")
+	flag.BoolVar(&cfg.KeepMounted, "keep-mounted", cfg.KeepMounted, "Leave a mount in place instead of dismounting it when the run finishes (only meaningful with -mount)")
+	portsFlag := flag.String("ports", "443", "Comma-separated list of ports to check on each onion")
+	onionsFlag := flag.String("onions", "", "Skip the image mount and scan phases and check onions from this newline-delimited file instead (use - for stdin)")
 	flag.Parse()
 
-	if *dismount {
-		fmt.Println("[*] Dismount requested")
+	ports, err := parsePorts(*portsFlag)
+	if err != nil {
+		exitError("invalid -ports", err)
+		return
+	}
+	cfg.Ports = ports
+
+	formatFlagValue := report.Format(*formatFlag)
+	switch formatFlagValue {
+	case report.FormatTable, report.FormatJSON, report.FormatCSV, report.FormatSARIF:
+	default:
+		exitError(fmt.Sprintf("unknown -format %q (want table, json, csv, or sarif)", *formatFlag), nil)
+		return
+	}
 
-		device, err := internal.GetLastMountedDevice()
+	if *onionsFlag != "" {
+		if err := runBatchCheck(cfg, *onionsFlag, formatFlagValue, *outputFlag); err != nil {
+			exitError("batch onion check failed", err)
+		}
+		return
+	}
+
+	if *listMounts {
+		entries, err := internal.ListMounts()
 		if err != nil {
-			fmt.Println("[!] Failed to find mounted device:", err)
+			exitError("failed to list mounts", err)
+			return
+		}
+		if len(entries) == 0 {
+			fmt.Println("[*] No active mounts")
 			return
 		}
+		for _, e := range entries {
+			fmt.Printf("  %s  %-9s %s -> %s (mounted %s)\n", e.ID, e.Backend, e.ImagePath, e.MountPoint, e.MountedAt.Format(time.RFC3339))
+		}
+		return
+	}
 
-		fmt.Println("[*] Dismounting device:", device)
+	if *dismountFlag != "" {
+		id := *dismountFlag
+		if id == "last" {
+			entries, err := internal.ListMounts()
+			if err != nil || len(entries) == 0 {
+				exitError("no active mounts to dismount", err)
+				return
+			}
+			id = entries[len(entries)-1].ID
+		}
 
-		if err := internal.Dismount(device); err != nil {
-			fmt.Println("[!] Failed to dismount device:", err)
+		fmt.Println("[*] Dismounting:", id)
+		if err := internal.DismountImage(id); err != nil {
+			exitError("failed to dismount", err)
 			return
 		}
-
-		internal.LogDismount(device)
 		fmt.Println("[+] Image dismounted successfully")
 		return
 	}
 
 	// --- Args ---
 	if flag.NArg() != 1 {
-		fmt.Fprintf(os.Stderr, "Usage: %s [--keep-mounted] <image.E01 | directory>\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "Usage: %s [flags] <image.E01 | raw image | VHD | directory>\n", os.Args[0])
+		flag.PrintDefaults()
 		return
 	}
 
 	inputPath := flag.Arg(0)
+	reportFormat := formatFlagValue
 
 	// --- Resolve absolute path ---
 	absPath, err := filepath.Abs(inputPath)
@@ -55,91 +115,102 @@ func main() {
 		return
 	}
 
-	info, err := os.Stat(absPath)
-	if err != nil {
+	if _, err := os.Stat(absPath); err != nil {
 		exitError("path does not exist", err)
 		return
 	}
 
-	var scanRoot string
-	var mounted bool
-	var deviceNumber string
-
-	// --- Case 1: directory (test mode) ---
-	if info.IsDir() {
-		fmt.Println("[!] Directory provided, scanning filesystem directly (test mode)")
-		scanRoot = absPath
+	var fsys fs.FS
+	var closer io.Closer
 
-	} else {
-		// --- Case 2: E01 ---
-		lower := strings.ToLower(absPath)
-		if !strings.HasSuffix(lower, ".e01") {
-			exitError("provided file is not an E01 or directory", nil)
+	if *mountFlag {
+		format, err := internal.DetectMountFormat(absPath)
+		if err != nil {
+			exitError("failed to detect image format", err)
 			return
 		}
-
-		fmt.Println("[+] E01 image accepted")
+		fmt.Printf("[+] Image accepted (format=%s)\n", format)
 		fmt.Println("    Path:", absPath)
 
-		mount, err := internal.MountE01(absPath)
+		entry, err := internal.MountImage(absPath)
 		if err != nil {
-			exitError("failed to mount E01", err)
+			exitError("failed to mount image", err)
 			return
 		}
+		fmt.Printf("[+] Mounted via %s: %s\n", entry.Backend, entry.MountPoint)
 
-		fmt.Println("[+] Image mounted")
-		fmt.Println("    Mount point :", mount.MountPoint)
-		fmt.Println("    Device num  :", mount.DeviceNumber)
-
-		scanRoot = mount.MountPoint
-		deviceNumber = mount.DeviceNumber
-		mounted = true
-
-		internal.LogMount(absPath, mount.DeviceNumber, mount.MountPoint, *keepMounted)
-	}
+		fsys = os.DirFS(entry.MountPoint)
+		closer = mountCloser{id: entry.ID, keep: cfg.KeepMounted}
+	} else {
+		format, err := imagefs.DetectFormat(absPath)
+		if err != nil {
+			exitError("failed to detect image format", err)
+			return
+		}
+		fmt.Printf("[+] Image accepted (format=%s)\n", format)
+		fmt.Println("    Path:", absPath)
 
-	// --- Ensure dismount unless --keep-mounted ---
-	if mounted && !*keepMounted {
-		defer func() {
-			fmt.Println("[*] Dismounting image...")
-			if err := internal.Dismount(deviceNumber); err != nil {
-				fmt.Println("[!] Failed to dismount:", err)
-			} else {
-				internal.LogDismount(deviceNumber)
-				fmt.Println("[+] Image dismounted")
-			}
-		}()
-	} else if mounted && *keepMounted {
-		fmt.Println("[!] Image will remain mounted (--keep-mounted enabled)")
+		fsys, closer, err = imagefs.Open(absPath)
+		if err != nil {
+			exitError("failed to open image", err)
+			return
+		}
 	}
+	defer closer.Close()
 
-	// --- Scan for .onion ---
-	fmt.Println("[*] Scanning filesystem for .onion domains...")
-	onions, err := scanner.ScanForOnions(scanRoot)
+	// --- Scan for IOCs (onions, BTC/ETH/Monero addresses, PGP blocks, credential tokens, ...) ---
+	fmt.Println("[*] Scanning filesystem for IOCs...")
+	hits, err := scanner.ScanForOnions(fsys, scanner.DefaultRuleSet(), cfg.ScanOptions())
 	if err != nil {
 		exitError("failed to scan filesystem", err)
 		return
 	}
 
-	fmt.Printf("[+] Found %d onion(s)\n", len(onions))
+	fmt.Printf("[+] Found %d hit(s)\n", len(hits))
 
 	// --- Write report ---
 	outputFile := "onion.txt"
-	if err := internal.WriteOnionReport(outputFile, onions); err != nil {
+	if err := internal.WriteOnionReport(outputFile, hits); err != nil {
 		exitError("failed to write onion report", err)
 		return
 	}
 
 	fmt.Println("[+] Report written:", outputFile)
+
+	// --- Extract co-located artifacts (BTC/onion/PGP/XMPP/email), so a
+	// wallet address or PGP key found next to an onion URL in the same file
+	// can be correlated by whoever reads artifacts.json ---
+	fmt.Println("[*] Extracting co-located artifacts...")
+	findings, err := scanner.Extract(fsys, cfg.ScanOptions(),
+		scanner.NewOnionExtractor(),
+		scanner.NewBitcoinExtractor(),
+		scanner.NewPGPExtractor(),
+		scanner.NewXMPPExtractor(),
+		scanner.NewEmailExtractor(),
+	)
+	if err != nil {
+		exitError("failed to extract artifacts", err)
+		return
+	}
+
+	artifactsFile := "artifacts.json"
+	if err := scanner.WriteFindingsJSON(artifactsFile, findings); err != nil {
+		exitError("failed to write artifacts report", err)
+		return
+	}
+
+	fmt.Printf("[+] Found %d artifact(s), written to %s\n", len(findings), artifactsFile)
 	fmt.Println("[+] Done.")
 
 	// --- Wait before Tor check ---
-	fmt.Println("[*] Waiting 30 seconds before Tor onion availability check...")
-	time.Sleep(30 * time.Second)
+	if cfg.BootstrapWait > 0 {
+		cfg.LogInfo("Waiting %s before Tor onion availability check...", cfg.BootstrapWait)
+		time.Sleep(cfg.BootstrapWait)
+	}
 
 	// --- Tor availability ---
 	fmt.Println("[*] Checking Tor availability...")
-	if err := tor.CheckTorAvailable(); err != nil {
+	if err := tor.CheckTorAvailable(cfg.TorProxyAddress); err != nil {
 		fmt.Println("[!] Tor not available:", err)
 		return
 	}
@@ -147,7 +218,7 @@ func main() {
 	fmt.Println("[+] Tor detected, checking onion services...")
 
 	// --- Onion availability check via Tor ---
-	results, err := tor.CheckOnions(outputFile, 443)
+	results, err := tor.CheckOnions(outputFile, cfg.CheckOptions())
 	if err != nil {
 		fmt.Println("[!] Onion check failed:", err)
 		return
@@ -161,6 +232,115 @@ func main() {
 	}
 
 	fmt.Println("[+] Onion availability results written to:", resultsFile)
+
+	// --- Write harvested TLS certificate chains, if any were collected ---
+	tlsFile := "results_tls.txt"
+	if err := tor.WriteTLSReport(tlsFile, results); err != nil {
+		fmt.Println("[!] Failed to write TLS report:", err)
+		return
+	}
+
+	fmt.Println("[+] TLS certificate chains written to:", tlsFile)
+
+	// --- Structured report (in addition to the ASCII table above) ---
+	if reportFormat != report.FormatTable {
+		now := time.Now()
+		records := report.FromHits(fsys, hits, now)
+		records = append(records, report.FromTorResults(results, now)...)
+
+		reportPath := *outputFlag
+		if reportPath == "" {
+			reportPath = reportFormat.DefaultFilename()
+		}
+
+		if err := report.WriteFile(reportFormat, reportPath, records); err != nil {
+			fmt.Println("[!] Failed to write structured report:", err)
+			return
+		}
+
+		fmt.Println("[+] Structured report written to:", reportPath)
+	}
+}
+
+// mountCloser releases a mount acquired via internal.MountImage when the
+// run is done, unless keep is set (the -keep-mounted flag), in which case
+// it leaves the mount in place for a later `-dismount <id>`.
+type mountCloser struct {
+	id   string
+	keep bool
+}
+
+func (c mountCloser) Close() error {
+	if c.keep {
+		return nil
+	}
+	return internal.DismountImage(c.id)
+}
+
+// runBatchCheck handles -onions: it skips the mount+scan phases entirely
+// and feeds a preexisting onion list straight into tor.CheckOnionsList, for
+// re-checking a list produced by an earlier run, a list from another tool,
+// or onions that never lived on a disk image at all.
+func runBatchCheck(cfg config.Config, onionsPath string, reportFormat report.Format, outputFlag string) error {
+	onions, err := readOnionList(onionsPath)
+	if err != nil {
+		return fmt.Errorf("reading onion list: %w", err)
+	}
+	fmt.Printf("[+] Loaded %d onion(s) from %s\n", len(onions), onionsPath)
+
+	fmt.Println("[*] Checking Tor availability...")
+	if err := tor.CheckTorAvailable(cfg.TorProxyAddress); err != nil {
+		return err
+	}
+
+	fmt.Println("[+] Tor detected, checking onion services...")
+	results, err := tor.CheckOnionsList(onions, cfg.CheckOptions())
+	if err != nil {
+		return fmt.Errorf("onion check failed: %w", err)
+	}
+
+	resultsFile := "results.txt"
+	if err := tor.WriteResultsTable(resultsFile, results); err != nil {
+		return fmt.Errorf("failed to write results: %w", err)
+	}
+	fmt.Println("[+] Onion availability results written to:", resultsFile)
+
+	tlsFile := "results_tls.txt"
+	if err := tor.WriteTLSReport(tlsFile, results); err != nil {
+		return fmt.Errorf("failed to write TLS report: %w", err)
+	}
+	fmt.Println("[+] TLS certificate chains written to:", tlsFile)
+
+	if reportFormat != report.FormatTable {
+		records := report.FromTorResults(results, time.Now())
+
+		reportPath := outputFlag
+		if reportPath == "" {
+			reportPath = reportFormat.DefaultFilename()
+		}
+		if err := report.WriteFile(reportFormat, reportPath, records); err != nil {
+			return fmt.Errorf("failed to write structured report: %w", err)
+		}
+		fmt.Println("[+] Structured report written to:", reportPath)
+	}
+
+	return nil
+}
+
+// readOnionList reads a newline-delimited onion list from path, or from
+// stdin when path is "-".
+func readOnionList(path string) ([]tor.Onion, error) {
+	if path == "-" {
+		return tor.ParseOnionList(os.Stdin)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return tor.ParseOnionList(file)
 }
 
 // ---------------- UTILS ----------------
@@ -171,3 +351,20 @@ func exitError(msg string, err error) {
 		fmt.Fprintf(os.Stderr, "[-] %s\n", msg)
 	}
 }
+
+// parsePorts parses a comma-separated port list, e.g. "80,443,8080".
+func parsePorts(s string) ([]int, error) {
+	var ports []int
+	for _, field := range strings.Split(s, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		port, err := strconv.Atoi(field)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a valid port", field)
+		}
+		ports = append(ports, port)
+	}
+	return ports, nil
+}